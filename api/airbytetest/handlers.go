@@ -0,0 +1,558 @@
+package airbytetest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type route struct {
+	method string
+	handle func(s *Server, body object) (interface{}, int)
+}
+
+var routes = map[string]route{
+	"/api/v1/health":              {"GET", handleHealth},
+	"/api/v1/deployment/metadata": {"POST", handleDeploymentMetadata},
+
+	"/api/v1/workspaces/list":   {"POST", handleListWorkspaces},
+	"/api/v1/workspaces/get":    {"POST", handleGetWorkspace},
+	"/api/v1/workspaces/create": {"POST", handleCreateWorkspace},
+
+	"/api/v1/sources/create":          {"POST", actorHandler(sourceKind, handleCreateActor)},
+	"/api/v1/sources/get":             {"POST", actorHandler(sourceKind, handleGetActor)},
+	"/api/v1/sources/update":          {"POST", actorHandler(sourceKind, handleUpdateActor)},
+	"/api/v1/sources/delete":          {"POST", actorHandler(sourceKind, handleDeleteActor)},
+	"/api/v1/sources/list":            {"POST", actorHandler(sourceKind, handleListActors)},
+	"/api/v1/sources/discover_schema": {"POST", handleDiscoverSchema},
+
+	"/api/v1/destinations/create": {"POST", actorHandler(destinationKind, handleCreateActor)},
+	"/api/v1/destinations/get":    {"POST", actorHandler(destinationKind, handleGetActor)},
+	"/api/v1/destinations/update": {"POST", actorHandler(destinationKind, handleUpdateActor)},
+	"/api/v1/destinations/delete": {"POST", actorHandler(destinationKind, handleDeleteActor)},
+	"/api/v1/destinations/list":   {"POST", actorHandler(destinationKind, handleListActors)},
+
+	"/api/v1/connections/create":           {"POST", handleCreateConnection},
+	"/api/v1/connections/get":              {"POST", handleGetConnection},
+	"/api/v1/connections/update":           {"POST", handleUpdateConnection},
+	"/api/v1/connections/delete":           {"POST", handleDeleteConnection},
+	"/api/v1/connections/list":             {"POST", handleListConnections},
+	"/api/v1/connections/sync":             {"POST", jobHandler("sync")},
+	"/api/v1/connections/reset":            {"POST", jobHandler("reset_connection")},
+	"/api/v1/web_backend/connections/list": {"POST", handleListWebBackendConnections},
+
+	"/api/v1/state/get":              {"POST", handleGetState},
+	"/api/v1/state/create_or_update": {"POST", handleCreateOrUpdateState},
+
+	"/api/v1/jobs/get":    {"POST", handleGetJob},
+	"/api/v1/jobs/list":   {"POST", handleListJobs},
+	"/api/v1/jobs/cancel": {"POST", handleCancelJob},
+}
+
+// Field names of sources and destinations, which only differ by prefix.
+type actorKind struct {
+	name            string
+	configType      string
+	idField         string
+	definitionField string
+	listField       string
+	objects         func(s *Server) map[string]object
+}
+
+var (
+	sourceKind = actorKind{
+		name:            "source",
+		configType:      "SOURCE_CONNECTION",
+		idField:         "sourceId",
+		definitionField: "sourceDefinitionId",
+		listField:       "sources",
+		objects:         func(s *Server) map[string]object { return s.sources },
+	}
+	destinationKind = actorKind{
+		name:            "destination",
+		configType:      "DESTINATION_CONNECTION",
+		idField:         "destinationId",
+		definitionField: "destinationDefinitionId",
+		listField:       "destinations",
+		objects:         func(s *Server) map[string]object { return s.destinations },
+	}
+)
+
+func actorHandler(kind actorKind, h func(s *Server, kind actorKind, body object) (interface{}, int)) func(s *Server, body object) (interface{}, int) {
+	return func(s *Server, body object) (interface{}, int) {
+		return h(s, kind, body)
+	}
+}
+
+func handleHealth(s *Server, body object) (interface{}, int) {
+	return object{"available": true}, http.StatusOK
+}
+
+func handleDeploymentMetadata(s *Server, body object) (interface{}, int) {
+	if s.version == "" {
+		return notFoundPath()
+	}
+	return object{
+		"id":          s.defaultWorkspaceId,
+		"mode":        "OSS",
+		"version":     s.version,
+		"environment": "test",
+	}, http.StatusOK
+}
+
+func handleListWorkspaces(s *Server, body object) (interface{}, int) {
+	return object{"workspaces": sorted(s.workspaces, "workspaceId", nil)}, http.StatusOK
+}
+
+func handleGetWorkspace(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "workspaceId")
+	w, ok := s.workspaces[id]
+	if !ok {
+		return notFound("STANDARD_WORKSPACE", id)
+	}
+	return w, http.StatusOK
+}
+
+func handleCreateWorkspace(s *Server, body object) (interface{}, int) {
+	if res, statusCode, ok := requireFields(body, "name"); !ok {
+		return res, statusCode
+	}
+
+	id := newId()
+	s.workspaces[id] = object{
+		"workspaceId": id,
+		"name":        body["name"],
+		"slug":        body["name"],
+	}
+	return s.workspaces[id], http.StatusOK
+}
+
+func handleCreateActor(s *Server, kind actorKind, body object) (interface{}, int) {
+	if res, statusCode, ok := requireFields(body, "name", "workspaceId", kind.definitionField, "connectionConfiguration"); !ok {
+		return res, statusCode
+	}
+	workspaceId := stringField(body, "workspaceId")
+	if _, ok := s.workspaces[workspaceId]; !ok {
+		return notFound("STANDARD_WORKSPACE", workspaceId)
+	}
+
+	id := newId()
+	actor := object{
+		kind.idField:              id,
+		"name":                    body["name"],
+		"workspaceId":             workspaceId,
+		kind.definitionField:      body[kind.definitionField],
+		"connectionConfiguration": body["connectionConfiguration"],
+	}
+	kind.objects(s)[id] = actor
+
+	return s.masked(kind, actor), http.StatusOK
+}
+
+func handleGetActor(s *Server, kind actorKind, body object) (interface{}, int) {
+	id := stringField(body, kind.idField)
+	actor, ok := kind.objects(s)[id]
+	if !ok {
+		return notFound(kind.configType, id)
+	}
+	return s.masked(kind, actor), http.StatusOK
+}
+
+func handleUpdateActor(s *Server, kind actorKind, body object) (interface{}, int) {
+	if res, statusCode, ok := requireFields(body, kind.idField, "name", "connectionConfiguration"); !ok {
+		return res, statusCode
+	}
+	id := stringField(body, kind.idField)
+	actor, ok := kind.objects(s)[id]
+	if !ok {
+		return notFound(kind.configType, id)
+	}
+
+	// Like Airbyte, masked secrets keep their stored value.
+	config, _ := body["connectionConfiguration"].(object)
+	prior, _ := actor["connectionConfiguration"].(object)
+	actor["name"] = body["name"]
+	actor["connectionConfiguration"] = unmaskSecrets(config, prior)
+
+	return s.masked(kind, actor), http.StatusOK
+}
+
+func handleDeleteActor(s *Server, kind actorKind, body object) (interface{}, int) {
+	id := stringField(body, kind.idField)
+	if _, ok := kind.objects(s)[id]; !ok {
+		return notFound(kind.configType, id)
+	}
+	delete(kind.objects(s), id)
+
+	// Connections of deleted sources and destinations are deleted too.
+	for connectionId, c := range s.connections {
+		if c[kind.idField] == id {
+			delete(s.connections, connectionId)
+			delete(s.states, connectionId)
+		}
+	}
+
+	return nil, http.StatusNoContent
+}
+
+func handleListActors(s *Server, kind actorKind, body object) (interface{}, int) {
+	workspaceId := stringField(body, "workspaceId")
+	actors := sorted(kind.objects(s), kind.idField, func(o object) bool {
+		return workspaceId == "" || o["workspaceId"] == workspaceId
+	})
+	for i, a := range actors {
+		actors[i] = s.masked(kind, a)
+	}
+	return object{kind.listField: actors}, http.StatusOK
+}
+
+func handleDiscoverSchema(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "sourceId")
+	if _, ok := s.sources[id]; !ok {
+		return notFound(sourceKind.configType, id)
+	}
+
+	catalog, ok := s.catalogs[id]
+	if !ok {
+		catalog = DefaultCatalog()
+	}
+
+	now := time.Now().Unix()
+	return object{
+		"catalog":   catalog,
+		"catalogId": newId(),
+		"jobInfo": object{
+			"id":         newId(),
+			"configType": "discover_schema",
+			"configId":   id,
+			"createdAt":  now,
+			"endedAt":    now,
+			"succeeded":  true,
+			"logs":       object{"logLines": []string{}},
+		},
+	}, http.StatusOK
+}
+
+func handleCreateConnection(s *Server, body object) (interface{}, int) {
+	if res, statusCode, ok := requireFields(body, "sourceId", "destinationId", "status"); !ok {
+		return res, statusCode
+	}
+	sourceId, destinationId := stringField(body, "sourceId"), stringField(body, "destinationId")
+	if _, ok := s.sources[sourceId]; !ok {
+		return notFound(sourceKind.configType, sourceId)
+	}
+	if _, ok := s.destinations[destinationId]; !ok {
+		return notFound(destinationKind.configType, destinationId)
+	}
+
+	id := newId()
+	connection := object{
+		"connectionId":                 id,
+		"name":                         "default",
+		"namespaceDefinition":          "source",
+		"prefix":                       "",
+		"scheduleType":                 "manual",
+		"operationIds":                 []interface{}{},
+		"geography":                    "auto",
+		"nonBreakingChangesPreference": "ignore",
+		"notifySchemaChanges":          false,
+		"syncCatalog":                  DefaultCatalog(),
+	}
+	for k, v := range body {
+		connection[k] = v
+	}
+	s.connections[id] = deepCopy(connection)
+
+	return s.connections[id], http.StatusOK
+}
+
+func handleGetConnection(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "connectionId")
+	connection, ok := s.connections[id]
+	if !ok {
+		return notFound("STANDARD_SYNC", id)
+	}
+	return connection, http.StatusOK
+}
+
+func handleUpdateConnection(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "connectionId")
+	connection, ok := s.connections[id]
+	if !ok {
+		return notFound("STANDARD_SYNC", id)
+	}
+
+	// Sources and destinations of connections can't be changed.
+	for k, v := range body {
+		if k == "sourceId" || k == "destinationId" {
+			continue
+		}
+		connection[k] = v
+	}
+	s.connections[id] = deepCopy(connection)
+
+	return s.connections[id], http.StatusOK
+}
+
+func handleDeleteConnection(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "connectionId")
+	if _, ok := s.connections[id]; !ok {
+		return notFound("STANDARD_SYNC", id)
+	}
+	delete(s.connections, id)
+	delete(s.states, id)
+
+	return nil, http.StatusNoContent
+}
+
+func handleListConnections(s *Server, body object) (interface{}, int) {
+	return object{"connections": s.workspaceConnections(stringField(body, "workspaceId"))}, http.StatusOK
+}
+
+func handleListWebBackendConnections(s *Server, body object) (interface{}, int) {
+	items := []object{}
+	for _, c := range s.workspaceConnections(stringField(body, "workspaceId")) {
+		source := s.sources[stringField(c, "sourceId")]
+		destination := s.destinations[stringField(c, "destinationId")]
+		items = append(items, object{
+			"connectionId": c["connectionId"],
+			"name":         c["name"],
+			"status":       c["status"],
+			"scheduleType": c["scheduleType"],
+			"source": object{
+				"sourceId":           c["sourceId"],
+				"name":               source["name"],
+				"sourceDefinitionId": source["sourceDefinitionId"],
+			},
+			"destination": object{
+				"destinationId":           c["destinationId"],
+				"name":                    destination["name"],
+				"destinationDefinitionId": destination["destinationDefinitionId"],
+			},
+		})
+	}
+	return object{"connections": items}, http.StatusOK
+}
+
+// Returns the connections whose source is in the workspace.
+func (s *Server) workspaceConnections(workspaceId string) []object {
+	return sorted(s.connections, "connectionId", func(c object) bool {
+		source := s.sources[stringField(c, "sourceId")]
+		return workspaceId == "" || (source != nil && source["workspaceId"] == workspaceId)
+	})
+}
+
+func handleGetState(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "connectionId")
+	if _, ok := s.connections[id]; !ok {
+		return notFound("STANDARD_SYNC", id)
+	}
+
+	state, ok := s.states[id]
+	if !ok {
+		return object{"stateType": "not_set", "connectionId": id}, http.StatusOK
+	}
+	return state, http.StatusOK
+}
+
+func handleCreateOrUpdateState(s *Server, body object) (interface{}, int) {
+	id := stringField(body, "connectionId")
+	if _, ok := s.connections[id]; !ok {
+		return notFound("STANDARD_SYNC", id)
+	}
+	state, ok := body["connectionState"].(object)
+	if !ok {
+		return validationError("connectionState", "is missing")
+	}
+
+	state["connectionId"] = id
+	s.states[id] = deepCopy(state)
+
+	return s.states[id], http.StatusOK
+}
+
+// Returns a handler which starts a job for a connection. Jobs succeed
+// right away, and resetting a connection clears its state.
+func jobHandler(configType string) func(s *Server, body object) (interface{}, int) {
+	return func(s *Server, body object) (interface{}, int) {
+		id := stringField(body, "connectionId")
+		if _, ok := s.connections[id]; !ok {
+			return notFound("STANDARD_SYNC", id)
+		}
+		if configType == "reset_connection" {
+			delete(s.states, id)
+		}
+
+		now := time.Now().Unix()
+		job := object{
+			"job": object{
+				"id":         s.nextJobId,
+				"configType": configType,
+				"configId":   id,
+				"status":     "succeeded",
+				"createdAt":  now,
+				"updatedAt":  now,
+			},
+			"attempts": []interface{}{},
+		}
+		s.jobs[s.nextJobId] = job
+		s.nextJobId++
+
+		return job, http.StatusOK
+	}
+}
+
+func handleGetJob(s *Server, body object) (interface{}, int) {
+	id, _ := body["id"].(float64)
+	job, ok := s.jobs[int64(id)]
+	if !ok {
+		return notFound("JOB", fmt.Sprint(int64(id)))
+	}
+	return job, http.StatusOK
+}
+
+func handleListJobs(s *Server, body object) (interface{}, int) {
+	configId := stringField(body, "configId")
+
+	ids := []int64{}
+	for id, j := range s.jobs {
+		if configId == "" || j["job"].(object)["configId"] == configId {
+			ids = append(ids, id)
+		}
+	}
+	// Newest first, like Airbyte.
+	sort.Slice(ids, func(i, j int) bool { return ids[i] > ids[j] })
+
+	jobs := []object{}
+	for _, id := range ids {
+		jobs = append(jobs, s.jobs[id])
+	}
+	return object{"jobs": jobs, "totalJobCount": len(jobs)}, http.StatusOK
+}
+
+func handleCancelJob(s *Server, body object) (interface{}, int) {
+	res, statusCode := handleGetJob(s, body)
+	if statusCode != http.StatusOK {
+		return res, statusCode
+	}
+	job := res.(object)["job"].(object)
+	if job["status"] == "running" || job["status"] == "pending" {
+		job["status"] = "cancelled"
+	}
+	return res, statusCode
+}
+
+// Returns a copy of the source or destination, with secrets masked
+// when the server is set to do so.
+func (s *Server) masked(kind actorKind, actor object) object {
+	c := deepCopy(actor)
+	if s.maskSecrets {
+		if config, ok := c["connectionConfiguration"].(object); ok {
+			maskSecrets(config, secretFields(stringField(c, kind.definitionField)))
+		}
+	}
+	return c
+}
+
+func maskSecrets(v interface{}, fields []string) {
+	switch t := v.(type) {
+	case object:
+		for k, val := range t {
+			if _, isString := val.(string); isString && isSecretField(k, fields) {
+				t[k] = SecretMask
+				continue
+			}
+			maskSecrets(val, fields)
+		}
+	case []interface{}:
+		for _, val := range t {
+			maskSecrets(val, fields)
+		}
+	}
+}
+
+// Replaces masked secrets in an updated configuration with their prior values.
+func unmaskSecrets(config object, prior object) object {
+	for k, v := range config {
+		switch t := v.(type) {
+		case string:
+			if t == SecretMask && prior != nil {
+				config[k] = prior[k]
+			}
+		case object:
+			p, _ := prior[k].(object)
+			config[k] = unmaskSecrets(t, p)
+		}
+	}
+	return config
+}
+
+// Returns the secret field names of the connector.
+func secretFields(definitionId string) []string {
+	if fields, ok := ConnectorSecretFields[definitionId]; ok {
+		return fields
+	}
+	return SecretFields
+}
+
+func isSecretField(name string, fields []string) bool {
+	for _, f := range fields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the objects ordered by ID, optionally filtered.
+func sorted(objects map[string]object, idField string, filter func(object) bool) []object {
+	list := []object{}
+	for _, o := range objects {
+		if filter == nil || filter(o) {
+			list = append(list, o)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return stringField(list[i], idField) < stringField(list[j], idField)
+	})
+	return list
+}
+
+func stringField(o object, field string) string {
+	s, _ := o[field].(string)
+	return s
+}
+
+func requireFields(body object, fields ...string) (interface{}, int, bool) {
+	for _, f := range fields {
+		if v, ok := body[f]; !ok || v == nil || v == "" {
+			res, statusCode := validationError(f, "must not be null")
+			return res, statusCode, false
+		}
+	}
+	return nil, 0, true
+}
+
+func notFound(configType string, id string) (interface{}, int) {
+	return object{
+		"message":            fmt.Sprintf("Could not find configuration for %s: %s.", configType, id),
+		"exceptionClassName": "io.airbyte.config.persistence.ConfigNotFoundException",
+		"exceptionStack":     []string{},
+	}, http.StatusNotFound
+}
+
+func notFoundPath() (interface{}, int) {
+	return object{"message": "Not found"}, http.StatusNotFound
+}
+
+func validationError(field string, message string) (interface{}, int) {
+	return object{
+		"message":            "Some properties contained invalid input.",
+		"exceptionClassName": "io.airbyte.server.errors.BadObjectSchemaKnownException",
+		"validationErrors": []object{{
+			"propertyPath": field,
+			"message":      message,
+		}},
+	}, http.StatusUnprocessableEntity
+}
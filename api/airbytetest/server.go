@@ -0,0 +1,401 @@
+// Package airbytetest provides an in-memory fake of the Airbyte config
+// API, to try out the API client and the provider resources offline.
+//
+// The fake keeps workspaces, sources, destinations, connections, their
+// state and jobs in memory, and can inject faults like latency, server
+// errors, malformed responses and masked secrets.
+package airbytetest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Credentials the server accepts by default.
+const (
+	Username = "airbyte"
+	Password = "password"
+)
+
+// Value Airbyte returns in place of secrets.
+const SecretMask = "**********"
+
+// Version the server reports by default.
+const DefaultVersion = "0.50.33"
+
+// Field names of connector configurations which hold secrets,
+// like those marked airbyte_secret in connector specifications. They
+// are masked for connectors without ConnectorSecretFields.
+var SecretFields = []string{
+	"access_token",
+	"api_key",
+	"api_password",
+	"api_token",
+	"apikey",
+	"client_secret",
+	"password",
+	"refresh_token",
+	"secret_key",
+}
+
+// Field names marked airbyte_secret in the specifications of the
+// connectors the provider has resources for, by definition ID. Some
+// connectors mark fields as secret which others don't, like client_id.
+var ConnectorSecretFields = map[string][]string{
+	// Stripe
+	"e094cb9a-26de-4645-8761-65c0c425d1de": {"client_secret"},
+	// HubSpot
+	"36c891d9-4bd9-43ac-bad2-10e12756272c": {"client_secret", "refresh_token", "access_token"},
+	// Shopify
+	"9da77001-af33-4bcd-be46-6252bf9342b9": {"api_password", "client_id", "client_secret", "access_token"},
+	// Zendesk Support
+	"79c1aa37-dae3-42ae-b333-d1c105477715": {"api_token", "client_id", "client_secret", "access_token"},
+	// Pipedrive
+	"d8286229-c680-4063-8c59-23b9b391c700": {"api_token", "client_secret", "refresh_token"},
+	// Mailchimp
+	"b03a9f3e-22a5-11eb-adc1-0242ac120002": {"apikey", "client_id", "client_secret", "access_token"},
+	// Amplitude
+	"fa9f58c6-2d03-4237-aaa4-07d75e0c1396": {"api_key", "secret_key"},
+	// Freshdesk
+	"ec4b9503-13cb-48ab-a4ab-6ade4be46567": {"api_key"},
+	// Local CSV
+	"8be1cf83-fde1-477f-a4ad-318d23c9f3c6": {},
+}
+
+// JSON object, as decoded from request bodies.
+type object = map[string]interface{}
+
+// Fault to inject into responses.
+type Fault struct {
+	// Request path to inject into, e.g. /api/v1/sources/create.
+	// Empty for all requests.
+	Path string
+
+	// Delay before responding.
+	Latency time.Duration
+
+	// Status code and raw body to respond with instead of handling the
+	// request, e.g. 502 with an HTML page, or 200 with malformed JSON.
+	// When both are zero, the request is handled after the latency.
+	StatusCode int
+	Body       string
+
+	// Number of requests to inject into, or 0 for all of them until
+	// the faults are cleared.
+	Times int
+}
+
+// Server is an in-memory fake of the Airbyte config API.
+// It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	username    string
+	password    string
+	version     string
+	maskSecrets bool
+	faults      []*Fault
+	requests    map[string]int
+
+	defaultWorkspaceId string
+	workspaces         map[string]object
+	sources            map[string]object
+	destinations       map[string]object
+	connections        map[string]object
+	states             map[string]object
+	jobs               map[int64]object
+	nextJobId          int64
+	catalogs           map[string]api.ConnSyncCatalog
+}
+
+// NewServer starts a fake server with a single workspace.
+// It has to be closed when done.
+func NewServer() *Server {
+	s := &Server{
+		username:     Username,
+		password:     Password,
+		version:      DefaultVersion,
+		requests:     map[string]int{},
+		workspaces:   map[string]object{},
+		sources:      map[string]object{},
+		destinations: map[string]object{},
+		connections:  map[string]object{},
+		states:       map[string]object{},
+		jobs:         map[int64]object{},
+		nextJobId:    1,
+		catalogs:     map[string]api.ConnSyncCatalog{},
+	}
+
+	s.defaultWorkspaceId = newId()
+	s.workspaces[s.defaultWorkspaceId] = object{
+		"workspaceId": s.defaultWorkspaceId,
+		"name":        "Default Workspace",
+		"slug":        "default",
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Host to configure clients with.
+func (s *Server) Host() string {
+	return s.URL
+}
+
+// WorkspaceId returns the ID of the default workspace.
+func (s *Server) WorkspaceId() string {
+	return s.defaultWorkspaceId
+}
+
+// SetCredentials changes the accepted credentials.
+// Empty credentials turn off authentication.
+func (s *Server) SetCredentials(username string, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.username, s.password = username, password
+}
+
+// SetVersion changes the reported server version.
+// An empty version makes the deployment metadata endpoint unavailable.
+func (s *Server) SetVersion(version string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.version = version
+}
+
+// SetMaskSecrets makes the server return SecretMask in place of secrets
+// in connector configurations, like Airbyte does.
+func (s *Server) SetMaskSecrets(mask bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maskSecrets = mask
+}
+
+// SetCatalog sets the catalog which discovering the schema of the
+// source returns. Sources without one return DefaultCatalog.
+func (s *Server) SetCatalog(sourceId string, catalog api.ConnSyncCatalog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.catalogs[sourceId] = catalog
+}
+
+// InjectFault adds a fault. Faults are matched in the order they are added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the number of requests received for the path,
+// e.g. /api/v1/sources/create.
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+// Source returns the stored source, with secrets unmasked.
+func (s *Server) Source(sourceId string) (map[string]interface{}, bool) {
+	return s.stored(s.sources, sourceId)
+}
+
+// Destination returns the stored destination, with secrets unmasked.
+func (s *Server) Destination(destinationId string) (map[string]interface{}, bool) {
+	return s.stored(s.destinations, destinationId)
+}
+
+// Connection returns the stored connection.
+func (s *Server) Connection(connectionId string) (map[string]interface{}, bool) {
+	return s.stored(s.connections, connectionId)
+}
+
+// ConnectionState returns the stored state of the connection.
+func (s *Server) ConnectionState(connectionId string) (map[string]interface{}, bool) {
+	return s.stored(s.states, connectionId)
+}
+
+func (s *Server) stored(objects map[string]object, id string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := objects[id]
+	if !ok {
+		return nil, false
+	}
+	return deepCopy(o), true
+}
+
+// DefaultCatalog is returned when discovering the schema of sources
+// without a catalog set.
+func DefaultCatalog() api.ConnSyncCatalog {
+	return api.ConnSyncCatalog{
+		Streams: []api.ConnSyncCatalogStream{
+			{
+				Stream: api.ConnAirbyteStream{
+					Name:                    "users",
+					JsonSchema:              json.RawMessage(`{"type":"object","properties":{"id":{"type":"string"},"updated_at":{"type":"string","format":"date-time"}}}`),
+					SupportedSyncModes:      []string{"full_refresh", "incremental"},
+					DefaultCursorField:      []string{"updated_at"},
+					SourceDefinedPrimaryKey: [][]string{{"id"}},
+				},
+				Config: api.ConnStreamConfig{
+					SyncMode:            "full_refresh",
+					DestinationSyncMode: "overwrite",
+					CursorField:         []string{"updated_at"},
+					PrimaryKey:          [][]string{{"id"}},
+					Selected:            true,
+				},
+			},
+			{
+				Stream: api.ConnAirbyteStream{
+					Name:               "orders",
+					JsonSchema:         json.RawMessage(`{"type":"object","properties":{"id":{"type":"string"}}}`),
+					SupportedSyncModes: []string{"full_refresh"},
+				},
+				Config: api.ConnStreamConfig{
+					SyncMode:            "full_refresh",
+					DestinationSyncMode: "append",
+					Selected:            true,
+				},
+			},
+		},
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests[r.URL.Path]++
+	fault := s.matchFault(r.URL.Path)
+	username, password := s.username, s.password
+	s.mu.Unlock()
+
+	if fault != nil {
+		time.Sleep(fault.Latency)
+		if fault.StatusCode != 0 || fault.Body != "" {
+			statusCode := fault.StatusCode
+			if statusCode == 0 {
+				statusCode = http.StatusOK
+			}
+			w.WriteHeader(statusCode)
+			io.WriteString(w, fault.Body)
+			return
+		}
+	}
+
+	if username != "" || password != "" {
+		u, p, ok := r.BasicAuth()
+		if !ok || u != username || p != password {
+			writeJSON(w, http.StatusUnauthorized, object{
+				"message": "Unauthorized",
+			})
+			return
+		}
+	}
+
+	h, ok := routes[r.URL.Path]
+	if !ok || r.Method != h.method {
+		writeJSON(w, http.StatusNotFound, object{
+			"message": fmt.Sprintf("%s %s not found", r.Method, r.URL.Path),
+		})
+		return
+	}
+
+	body := object{}
+	b, err := io.ReadAll(r.Body)
+	if err == nil && len(strings.TrimSpace(string(b))) > 0 {
+		err = json.Unmarshal(b, &body)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, object{
+			"message":            "Invalid JSON request body: " + err.Error(),
+			"exceptionClassName": "com.fasterxml.jackson.core.JsonParseException",
+		})
+		return
+	}
+
+	// Encoded while locked, as responses may share stored objects.
+	s.mu.Lock()
+	res, statusCode := h.handle(s, body)
+	b, err = json.Marshal(res)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if res != nil {
+		w.Write(b)
+	}
+}
+
+// Returns the fault to inject into a request for the path, if any.
+func (s *Server) matchFault(path string) *Fault {
+	for i, f := range s.faults {
+		if f.Path != "" && f.Path != path {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+// Returns a random UUID.
+func newId() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func deepCopy(o object) object {
+	b, _ := json.Marshal(o)
+	c := object{}
+	json.Unmarshal(b, &c)
+	return c
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Names of the available backends.
+const (
+	BackendConfig = "config"
+	BackendPublic = "public"
+)
+
+// ErrUnsupportedOperation is returned for operations which the
+// selected backend can't carry out.
+var ErrUnsupportedOperation = errors.New("operation not supported by the API backend")
+
+// Backend carries out API operations against one of the Airbyte APIs.
+// Operations are named after the config API endpoints, e.g. sources/create,
+// and their request and response bodies always have the config API shape,
+// so that the typed client methods work the same on every backend.
+type Backend interface {
+	Name() string
+
+	// Call carries out the operation and returns the response body and
+	// status code. Error bodies have the config API error shape.
+	// A zero timeout uses the client default.
+	Call(c *Client, operation string, body []byte, timeout time.Duration) ([]byte, int, error)
+}
+
+func newBackend(name string) (Backend, error) {
+	switch name {
+	case "", BackendConfig:
+		return configBackend{}, nil
+	case BackendPublic:
+		return publicBackend{}, nil
+	default:
+		return nil, fmt.Errorf("invalid API backend %q, expected one of config or public", name)
+	}
+}
+
+func unsupportedOperation(backend string, operation string) error {
+	return fmt.Errorf(
+		"%w: %s is not available with the %s API backend, use the config API backend for it",
+		ErrUnsupportedOperation, operation, backend,
+	)
+}
+
+func (c *Client) call(operation string, body []byte) ([]byte, int, error) {
+	return c.Backend.Call(c, operation, body, 0)
+}
+
+func (c *Client) callWithTimeout(operation string, body []byte, timeout time.Duration) ([]byte, int, error) {
+	return c.Backend.Call(c, operation, body, timeout)
+}
+
+// Backend for the internal config API, e.g. /api/v1/sources/create.
+type configBackend struct{}
+
+func (configBackend) Name() string {
+	return BackendConfig
+}
+
+func (configBackend) Call(c *Client, operation string, body []byte, timeout time.Duration) ([]byte, int, error) {
+	method := "POST"
+	if operation == "health" {
+		method = "GET"
+	}
+	url := c.Host + "/api/v1/" + operation
+
+	var (
+		b          []byte
+		statusCode int
+		err        error
+	)
+	if timeout > 0 {
+		b, statusCode, _, _, err = c.doRequestWithTimeout(method, url, body, nil, timeout)
+	} else {
+		b, statusCode, _, _, err = c.doRequest(method, url, body, nil)
+	}
+
+	return b, statusCode, err
+}
@@ -0,0 +1,568 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path of the public API, relative to the host.
+const publicAPIPath = "/api/public/v1"
+
+// Number of items requested per page by list operations.
+const publicPageSize = 100
+
+// Backend for the public REST API, e.g. POST /api/public/v1/sources.
+// It has no schema discovery and no connection state, and connection
+// streams are configured by name only.
+type publicBackend struct{}
+
+// Field names of sources and destinations, which only differ by prefix.
+type publicActor struct {
+	path            string
+	idField         string
+	definitionField string
+}
+
+var publicActors = map[string]publicActor{
+	"sources": {
+		path:            "/sources",
+		idField:         "sourceId",
+		definitionField: "sourceDefinitionId",
+	},
+	"destinations": {
+		path:            "/destinations",
+		idField:         "destinationId",
+		definitionField: "destinationDefinitionId",
+	},
+}
+
+type publicConnection struct {
+	ConnectionId                     string                      `json:"connectionId,omitempty"`
+	Name                             string                      `json:"name,omitempty"`
+	SourceId                         string                      `json:"sourceId,omitempty"`
+	DestinationId                    string                      `json:"destinationId,omitempty"`
+	WorkspaceId                      string                      `json:"workspaceId,omitempty"`
+	Status                           string                      `json:"status,omitempty"`
+	Schedule                         *publicConnectionSchedule   `json:"schedule,omitempty"`
+	NamespaceDefinition              string                      `json:"namespaceDefinition,omitempty"`
+	NamespaceFormat                  string                      `json:"namespaceFormat,omitempty"`
+	Prefix                           string                      `json:"prefix,omitempty"`
+	DataResidency                    string                      `json:"dataResidency,omitempty"`
+	NonBreakingSchemaUpdatesBehavior string                      `json:"nonBreakingSchemaUpdatesBehavior,omitempty"`
+	Configurations                   *publicStreamConfigurations `json:"configurations,omitempty"`
+}
+
+type publicConnectionSchedule struct {
+	ScheduleType   string `json:"scheduleType"`
+	CronExpression string `json:"cronExpression,omitempty"`
+	BasicTiming    string `json:"basicTiming,omitempty"`
+}
+
+type publicStreamConfigurations struct {
+	Streams []publicStreamConfiguration `json:"streams"`
+}
+
+type publicStreamConfiguration struct {
+	Name        string     `json:"name"`
+	SyncMode    string     `json:"syncMode,omitempty"`
+	CursorField []string   `json:"cursorField,omitempty"`
+	PrimaryKey  [][]string `json:"primaryKey,omitempty"`
+}
+
+// Error body of the public API.
+type publicProblem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+// Values which differ between the config API and the public API.
+var (
+	publicNamespaceDefinitions = map[string]string{
+		"customformat": "custom_format",
+	}
+	publicNonBreakingChangesPreferences = map[string]string{
+		"disable": "disable_connection",
+	}
+	publicSyncModes = map[string]string{
+		"incremental_append_dedup": "incremental_deduped_history",
+	}
+)
+
+func (publicBackend) Name() string {
+	return BackendPublic
+}
+
+func (p publicBackend) Call(c *Client, operation string, body []byte, timeout time.Duration) ([]byte, int, error) {
+	resource, action, _ := strings.Cut(operation, "/")
+
+	switch resource {
+	case "health":
+		b, statusCode, err := p.request(c, "GET", "/health", nil)
+		if err != nil || statusCode < 200 || statusCode > 299 {
+			return b, statusCode, err
+		}
+		return []byte(`{"available":true}`), statusCode, nil
+
+	case "workspaces":
+		if action == "list" {
+			return p.list(c, "workspaces", "/workspaces", url.Values{}, func(item json.RawMessage) (interface{}, error) {
+				return item, nil
+			})
+		}
+
+	case "sources", "destinations":
+		actor := publicActors[resource]
+		switch action {
+		case "create", "get", "update", "delete", "list":
+			return p.callActor(c, resource, actor, action, body)
+		}
+
+	case "web_backend":
+		if action == "connections/list" {
+			return p.callConnection(c, "web_backend_list", body)
+		}
+
+	case "connections":
+		switch action {
+		case "create", "get", "update", "delete", "list":
+			return p.callConnection(c, action, body)
+		}
+	}
+
+	return nil, 0, unsupportedOperation(p.Name(), operation)
+}
+
+func (p publicBackend) callActor(c *Client, resource string, actor publicActor, action string, body []byte) ([]byte, int, error) {
+	in := map[string]json.RawMessage{}
+	err := json.Unmarshal(body, &in)
+	if err != nil {
+		return nil, 0, err
+	}
+	id := url.PathEscape(stringField(in, actor.idField))
+
+	toConfig := func(b []byte) []byte {
+		out := map[string]json.RawMessage{}
+		if json.Unmarshal(b, &out) != nil {
+			return b
+		}
+		converted, err := json.Marshal(actor.toConfig(out))
+		if err != nil {
+			return b
+		}
+		return converted
+	}
+
+	var (
+		b          []byte
+		statusCode int
+	)
+	switch action {
+	case "create":
+		req := map[string]json.RawMessage{}
+		copyField(req, "name", in, "name")
+		copyField(req, "workspaceId", in, "workspaceId")
+		copyField(req, "definitionId", in, actor.definitionField)
+		copyField(req, "configuration", in, "connectionConfiguration")
+		reqBody, err := json.Marshal(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		b, statusCode, err = p.request(c, "POST", actor.path, reqBody)
+		if err != nil {
+			return nil, statusCode, err
+		}
+
+	case "get":
+		b, statusCode, err = p.request(c, "GET", actor.path+"/"+id, nil)
+		if err != nil {
+			return nil, statusCode, err
+		}
+
+	case "update":
+		req := map[string]json.RawMessage{}
+		copyField(req, "name", in, "name")
+		copyField(req, "configuration", in, "connectionConfiguration")
+		reqBody, err := json.Marshal(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		b, statusCode, err = p.request(c, "PUT", actor.path+"/"+id, reqBody)
+		if err != nil {
+			return nil, statusCode, err
+		}
+
+	case "delete":
+		return p.request(c, "DELETE", actor.path+"/"+id, nil)
+
+	case "list":
+		query := url.Values{}
+		if workspaceId := stringField(in, "workspaceId"); workspaceId != "" {
+			query.Set("workspaceIds", workspaceId)
+		}
+		return p.list(c, resource, actor.path, query, func(item json.RawMessage) (interface{}, error) {
+			return json.RawMessage(toConfig(item)), nil
+		})
+	}
+
+	if statusCode >= 200 && statusCode <= 299 {
+		b = toConfig(b)
+	}
+	return b, statusCode, nil
+}
+
+func (p publicBackend) callConnection(c *Client, action string, body []byte) ([]byte, int, error) {
+	in := ConnectionResource{}
+	err := json.Unmarshal(body, &in)
+	if err != nil {
+		return nil, 0, err
+	}
+	id := url.PathEscape(in.ConnectionID)
+
+	var (
+		b          []byte
+		statusCode int
+	)
+	switch action {
+	case "create", "update":
+		req, err := connectionToPublic(in)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		method, path := "POST", "/connections"
+		if action == "update" {
+			// Only the connection settings can be patched.
+			req.SourceId = ""
+			req.DestinationId = ""
+			method, path = "PATCH", "/connections/"+id
+		}
+
+		reqBody, err := json.Marshal(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		b, statusCode, err = p.request(c, method, path, reqBody)
+		if err != nil {
+			return nil, statusCode, err
+		}
+
+	case "get":
+		b, statusCode, err = p.request(c, "GET", "/connections/"+id, nil)
+		if err != nil {
+			return nil, statusCode, err
+		}
+
+	case "delete":
+		return p.request(c, "DELETE", "/connections/"+id, nil)
+
+	case "list", "web_backend_list":
+		filter := WorkspaceIdRequestBody{}
+		err = json.Unmarshal(body, &filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		query := url.Values{}
+		if filter.WorkspaceId != "" {
+			query.Set("workspaceIds", filter.WorkspaceId)
+		}
+		return p.list(c, "connections", "/connections", query, func(item json.RawMessage) (interface{}, error) {
+			connection := publicConnection{}
+			err := json.Unmarshal(item, &connection)
+			if err != nil {
+				return nil, err
+			}
+			if action == "web_backend_list" {
+				return WebBackendConnectionListItem{
+					ConnectionId: connection.ConnectionId,
+					Name:         connection.Name,
+					Source:       SourceSnippetRead{connection.SourceId},
+					Destination:  DestinationSnippetRead{connection.DestinationId},
+				}, nil
+			}
+			return connectionFromPublic(connection), nil
+		})
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		return b, statusCode, nil
+	}
+
+	connection := publicConnection{}
+	err = json.Unmarshal(b, &connection)
+	if err != nil {
+		// Left for the caller to report.
+		return b, statusCode, nil
+	}
+	converted, err := json.Marshal(connectionFromPublic(connection))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return converted, statusCode, nil
+}
+
+// Sends a request to the public API. Error bodies are returned in
+// the config API error shape.
+func (p publicBackend) request(c *Client, method string, path string, body []byte) ([]byte, int, error) {
+	b, statusCode, _, _, err := c.doRequest(method, c.Host+publicAPIPath+path, body, nil)
+	if err != nil {
+		return nil, statusCode, err
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		b = publicErrorBody(b)
+	}
+
+	return b, statusCode, nil
+}
+
+// Fetches all pages of a list endpoint, and returns the converted items
+// like the config API does, e.g. {"sources": [...]}.
+func (p publicBackend) list(c *Client, key string, path string, query url.Values, convert func(json.RawMessage) (interface{}, error)) ([]byte, int, error) {
+	items := []interface{}{}
+
+	for offset := 0; ; offset += publicPageSize {
+		query.Set("limit", strconv.Itoa(publicPageSize))
+		query.Set("offset", strconv.Itoa(offset))
+
+		b, statusCode, err := p.request(c, "GET", path+"?"+query.Encode(), nil)
+		if err != nil || statusCode < 200 || statusCode > 299 {
+			return b, statusCode, err
+		}
+
+		var page struct {
+			Data []json.RawMessage `json:"data"`
+			Next string            `json:"next"`
+		}
+		err = json.Unmarshal(b, &page)
+		if err != nil {
+			// Left for the caller to report.
+			return b, statusCode, nil
+		}
+
+		for _, item := range page.Data {
+			converted, err := convert(item)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, converted)
+		}
+
+		if page.Next == "" || len(page.Data) < publicPageSize {
+			break
+		}
+	}
+
+	b, err := json.Marshal(map[string]interface{}{key: items})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return b, 200, nil
+}
+
+// Maps a public API source or destination to the config API shape.
+func (a publicActor) toConfig(in map[string]json.RawMessage) map[string]json.RawMessage {
+	out := map[string]json.RawMessage{}
+	copyField(out, a.idField, in, a.idField)
+	copyField(out, "name", in, "name")
+	copyField(out, "workspaceId", in, "workspaceId")
+	copyField(out, a.definitionField, in, "definitionId")
+	copyField(out, "connectionConfiguration", in, "configuration")
+	return out
+}
+
+// Maps a config API connection to the public API shape.
+// Settings the public API has no equivalent for are rejected,
+// instead of silently being dropped.
+func connectionToPublic(in ConnectionResource) (publicConnection, error) {
+	out := publicConnection{
+		ConnectionId:        in.ConnectionID,
+		Name:                in.Name,
+		SourceId:            in.SourceID,
+		DestinationId:       in.DestinationID,
+		Status:              in.Status,
+		NamespaceDefinition: mapValue(publicNamespaceDefinitions, in.NamespaceDefinition),
+		NamespaceFormat:     in.NamespaceFormat,
+		Prefix:              in.Prefix,
+		DataResidency:       in.Geography,
+
+		NonBreakingSchemaUpdatesBehavior: mapValue(publicNonBreakingChangesPreferences, in.NonBreakingChangesPreference),
+	}
+
+	if len(in.OperationIDs) > 0 {
+		return out, unsupportedOperation(BackendPublic, "connection operations")
+	}
+	if in.NotifySchemaChanges != nil {
+		return out, unsupportedOperation(BackendPublic, "notify_schema_changes")
+	}
+
+	switch in.ScheduleType {
+	case "":
+	case "manual":
+		out.Schedule = &publicConnectionSchedule{ScheduleType: "manual"}
+	case "cron":
+		zone := in.ScheduleData.Cron.CronTimeZone
+		if zone != "" && zone != "UTC" {
+			return out, fmt.Errorf("the public API backend only supports cron schedules in UTC, got time zone %s", zone)
+		}
+		out.Schedule = &publicConnectionSchedule{
+			ScheduleType:   "cron",
+			CronExpression: in.ScheduleData.Cron.CronExpression,
+		}
+	default:
+		return out, fmt.Errorf(
+			"the public API backend does not support %s schedules, use a cron schedule instead", in.ScheduleType,
+		)
+	}
+
+	if in.SyncCatalog != nil {
+		out.Configurations = &publicStreamConfigurations{Streams: []publicStreamConfiguration{}}
+		for _, s := range in.SyncCatalog.Streams {
+			if !s.Config.Selected {
+				continue
+			}
+			if s.Stream.Namespace != "" {
+				return out, unsupportedOperation(BackendPublic, "stream namespaces")
+			}
+			if s.Config.AliasName != "" {
+				return out, unsupportedOperation(BackendPublic, "stream alias names")
+			}
+
+			syncMode := ""
+			if (s.Config.SyncMode == "") != (s.Config.DestinationSyncMode == "") {
+				return out, fmt.Errorf(
+					"the public API backend needs both the sync_mode and destination_sync_mode of stream %s, or neither",
+					s.Stream.Name,
+				)
+			}
+			if s.Config.SyncMode != "" {
+				syncMode = s.Config.SyncMode + "_" + s.Config.DestinationSyncMode
+				syncMode = mapValue(publicSyncModes, syncMode)
+			}
+
+			out.Configurations.Streams = append(out.Configurations.Streams, publicStreamConfiguration{
+				Name:        s.Stream.Name,
+				SyncMode:    syncMode,
+				CursorField: s.Config.CursorField,
+				PrimaryKey:  s.Config.PrimaryKey,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+// Maps a public API connection to the config API shape.
+func connectionFromPublic(in publicConnection) ConnectionResource {
+	out := ConnectionResource{
+		ConnectionID:        in.ConnectionId,
+		Name:                in.Name,
+		SourceID:            in.SourceId,
+		DestinationID:       in.DestinationId,
+		Status:              in.Status,
+		NamespaceDefinition: mapValueReverse(publicNamespaceDefinitions, in.NamespaceDefinition),
+		NamespaceFormat:     in.NamespaceFormat,
+		Prefix:              in.Prefix,
+		Geography:           in.DataResidency,
+
+		NonBreakingChangesPreference: mapValueReverse(publicNonBreakingChangesPreferences, in.NonBreakingSchemaUpdatesBehavior),
+	}
+
+	if in.Schedule != nil {
+		out.ScheduleType = in.Schedule.ScheduleType
+		switch in.Schedule.ScheduleType {
+		case "cron":
+			out.ScheduleData.Cron.CronExpression = in.Schedule.CronExpression
+			out.ScheduleData.Cron.CronTimeZone = "UTC"
+		case "basic":
+			// Timing like "Every 24 hours".
+			var units int64
+			var timeUnit string
+			_, err := fmt.Sscanf(in.Schedule.BasicTiming, "Every %d %s", &units, &timeUnit)
+			if err == nil {
+				out.ScheduleData.BasicSchedule.Units = units
+				out.ScheduleData.BasicSchedule.TimeUnit = strings.ToLower(timeUnit)
+			}
+		}
+	}
+
+	if in.Configurations != nil {
+		out.SyncCatalog = &ConnSyncCatalog{Streams: []ConnSyncCatalogStream{}}
+		for _, s := range in.Configurations.Streams {
+			stream := ConnSyncCatalogStream{}
+			stream.Stream.Name = s.Name
+
+			// Like full_refresh_overwrite or incremental_append.
+			syncMode := mapValueReverse(publicSyncModes, s.SyncMode)
+			for _, mode := range []string{"full_refresh", "incremental"} {
+				if strings.HasPrefix(syncMode, mode+"_") {
+					stream.Config.SyncMode = mode
+					stream.Config.DestinationSyncMode = strings.TrimPrefix(syncMode, mode+"_")
+				}
+			}
+			stream.Config.CursorField = s.CursorField
+			stream.Config.PrimaryKey = s.PrimaryKey
+			stream.Config.Selected = true
+
+			out.SyncCatalog.Streams = append(out.SyncCatalog.Streams, stream)
+		}
+	}
+
+	return out
+}
+
+// Converts a public API error to the config API error shape.
+func publicErrorBody(b []byte) []byte {
+	problem := publicProblem{}
+	if json.Unmarshal(b, &problem) != nil || (problem.Title == "" && problem.Detail == "") {
+		return b
+	}
+
+	apiErr := APIError{
+		Message:            problem.Detail,
+		ExceptionClassName: problem.Type,
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = problem.Title
+	}
+
+	converted, err := json.Marshal(apiErr)
+	if err != nil {
+		return b
+	}
+	return converted
+}
+
+func copyField(dst map[string]json.RawMessage, dstKey string, src map[string]json.RawMessage, srcKey string) {
+	if v, ok := src[srcKey]; ok {
+		dst[dstKey] = v
+	}
+}
+
+func stringField(m map[string]json.RawMessage, key string) string {
+	s := ""
+	json.Unmarshal(m[key], &s)
+	return s
+}
+
+func mapValue(values map[string]string, v string) string {
+	if mapped, ok := values[v]; ok {
+		return mapped
+	}
+	return v
+}
+
+func mapValueReverse(values map[string]string, v string) string {
+	for from, to := range values {
+		if to == v {
+			return from
+		}
+	}
+	return v
+}
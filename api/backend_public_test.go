@@ -0,0 +1,93 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Starts a public API stub whose connections only keep the streams
+// named in known, like a server skipping streams it doesn't know.
+func newPublicServer(t *testing.T, known ...string) *httptest.Server {
+	t.Helper()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/public/v1/connections" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		connection := map[string]interface{}{}
+		err := json.NewDecoder(r.Body).Decode(&connection)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		streams := []interface{}{}
+		if configurations, ok := connection["configurations"].(map[string]interface{}); ok {
+			for _, s := range configurations["streams"].([]interface{}) {
+				for _, name := range known {
+					if s.(map[string]interface{})["name"] == name {
+						streams = append(streams, s)
+					}
+				}
+			}
+		}
+		connection["connectionId"] = "c1"
+		connection["configurations"] = map[string]interface{}{"streams": streams}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(connection)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newPublicConnection(streams ...string) api.ConnectionResource {
+	catalog := api.ConnSyncCatalog{}
+	for _, name := range streams {
+		stream := api.ConnSyncCatalogStream{}
+		stream.Stream.Name = name
+		stream.Config.Selected = true
+		catalog.Streams = append(catalog.Streams, stream)
+	}
+
+	return api.ConnectionResource{
+		Name:          "connection",
+		SourceID:      "s1",
+		DestinationID: "d1",
+		Status:        "active",
+		SyncCatalog:   &catalog,
+	}
+}
+
+func TestPublicBackendSyncCatalog(t *testing.T) {
+	s := newPublicServer(t, "customers")
+	c, err := api.NewClient(api.ClientConfig{Host: s.URL, Backend: api.BackendPublic})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	_, err = c.CreateConnectionResource(newPublicConnection("customers"))
+	if err != nil {
+		t.Errorf("CreateConnectionResource() error = %v", err)
+	}
+
+	_, err = c.CreateConnectionResource(newPublicConnection("customers", "invoices"))
+	if err == nil || !strings.Contains(err.Error(), "invoices") {
+		t.Errorf("CreateConnectionResource() error = %v, want an error naming the skipped stream", err)
+	}
+
+	connection := newPublicConnection("customers")
+	connection.SyncCatalog.Streams[0].Config.SyncMode = "incremental"
+	_, err = c.CreateConnectionResource(connection)
+	if err == nil {
+		t.Error("CreateConnectionResource() error = nil, want an error for a sync mode without destination sync mode")
+	}
+}
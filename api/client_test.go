@@ -0,0 +1,331 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+	"github.com/zipstack/pct-provider-airbyte-local/api/airbytetest"
+)
+
+// Definition IDs of the connectors used in the tests.
+const (
+	stripeDefinitionId   = "e094cb9a-26de-4645-8761-65c0c425d1de"
+	localCSVDefinitionId = "8be1cf83-fde1-477f-a4ad-318d23c9f3c6"
+)
+
+func newTestClient(t *testing.T, s *airbytetest.Server) *api.Client {
+	t.Helper()
+
+	c, err := api.NewClient(api.ClientConfig{
+		Host:     s.Host(),
+		Username: airbytetest.Username,
+		Password: airbytetest.Password,
+	})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c
+}
+
+func newStripeSource(workspaceId string) api.SourceStripe {
+	return api.SourceStripe{
+		Name:               "stripe",
+		SourceDefinitionId: stripeDefinitionId,
+		WorkspaceId:        workspaceId,
+		ConnectionConfiguration: api.SourceStripeConnConfig{
+			StartDate:    "2017-01-25T00:00:00Z",
+			ClientSecret: "sk_test_secret",
+			AccountId:    "acct_123",
+		},
+	}
+}
+
+func TestStripeSourceLifecycle(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	created, err := c.CreateStripeSource(newStripeSource(s.WorkspaceId()))
+	if err != nil {
+		t.Fatalf("CreateStripeSource() error = %v", err)
+	}
+	if created.SourceId == "" {
+		t.Fatal("CreateStripeSource() returned no source ID")
+	}
+
+	read, err := c.ReadStripeSource(created.SourceId)
+	if err != nil {
+		t.Fatalf("ReadStripeSource() error = %v", err)
+	}
+	if read.Name != "stripe" || read.ConnectionConfiguration.AccountId != "acct_123" {
+		t.Errorf("ReadStripeSource() = %+v, want the created source", read)
+	}
+
+	update := read
+	update.Name = "stripe renamed"
+	update.ConnectionConfiguration.ClientSecret = "sk_test_rotated"
+	updated, err := c.UpdateStripeSource(update)
+	if err != nil {
+		t.Fatalf("UpdateStripeSource() error = %v", err)
+	}
+	if updated.Name != "stripe renamed" {
+		t.Errorf("UpdateStripeSource() name = %q, want %q", updated.Name, "stripe renamed")
+	}
+	stored, _ := s.Source(created.SourceId)
+	config, _ := stored["connectionConfiguration"].(map[string]interface{})
+	if config["client_secret"] != "sk_test_rotated" {
+		t.Errorf("stored client_secret = %v, want the rotated secret", config["client_secret"])
+	}
+
+	err = c.DeleteStripeSource(created.SourceId)
+	if err != nil {
+		t.Fatalf("DeleteStripeSource() error = %v", err)
+	}
+
+	_, err = c.ReadStripeSource(created.SourceId)
+	apiErr := &api.Error{}
+	if !errors.As(err, &apiErr) || !apiErr.IsUserError() {
+		t.Errorf("ReadStripeSource() after delete error = %v, want a not found error", err)
+	}
+}
+
+func TestConnectionLifecycle(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	source, err := c.CreateStripeSource(newStripeSource(s.WorkspaceId()))
+	if err != nil {
+		t.Fatalf("CreateStripeSource() error = %v", err)
+	}
+	destination, err := c.CreateLocalCSVDestination(api.DestinationLocalCSV{
+		Name:                    "csv",
+		DestinationDefinitionId: localCSVDefinitionId,
+		WorkspaceId:             s.WorkspaceId(),
+		ConnectionConfiguration: api.DestinationLocalCSVConnConfigModel{
+			DestinationPath: "/local/stripe",
+			DelimiterType:   api.DestinationDelimiterConfigModel{Delimiter: "\\u002c"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateLocalCSVDestination() error = %v", err)
+	}
+
+	wanted := api.ConnSyncCatalog{}
+	stream := api.ConnSyncCatalogStream{}
+	stream.Stream.Name = "users"
+	stream.Config.SyncMode = "full_refresh"
+	stream.Config.DestinationSyncMode = "overwrite"
+	wanted.Streams = append(wanted.Streams, stream)
+
+	created, err := c.CreateConnectionResource(api.ConnectionResource{
+		Name:          "stripe to csv",
+		SourceID:      source.SourceId,
+		DestinationID: destination.DestinationId,
+		Status:        "active",
+		ScheduleType:  "manual",
+		SyncCatalog:   &wanted,
+	})
+	if err != nil {
+		t.Fatalf("CreateConnectionResource() error = %v", err)
+	}
+	for _, s := range created.SyncCatalog.Streams {
+		if s.Config.Selected != (s.Stream.Name == "users") {
+			t.Errorf("stream %s selected = %t, want only users selected", s.Stream.Name, s.Config.Selected)
+		}
+	}
+
+	read, err := c.ReadConnectionResource(created.ConnectionID)
+	if err != nil {
+		t.Fatalf("ReadConnectionResource() error = %v", err)
+	}
+	read.Status = "inactive"
+	updated, err := c.UpdateConnectionResource(read)
+	if err != nil {
+		t.Fatalf("UpdateConnectionResource() error = %v", err)
+	}
+	if updated.Status != "inactive" {
+		t.Errorf("UpdateConnectionResource() status = %q, want inactive", updated.Status)
+	}
+
+	unknown := api.ConnSyncCatalog{Streams: []api.ConnSyncCatalogStream{{}}}
+	unknown.Streams[0].Stream.Name = "invoices"
+	read.SyncCatalog = &unknown
+	_, err = c.UpdateConnectionResource(read)
+	if err == nil || !strings.Contains(err.Error(), "invoices") {
+		t.Errorf("UpdateConnectionResource() error = %v, want an error naming the unknown stream", err)
+	}
+
+	err = c.DeleteConnectionResource(created.ConnectionID)
+	if err != nil {
+		t.Fatalf("DeleteConnectionResource() error = %v", err)
+	}
+	if _, ok := s.Connection(created.ConnectionID); ok {
+		t.Error("connection still stored after DeleteConnectionResource()")
+	}
+}
+
+func TestFaultLatency(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+	c.HTTPClient.Timeout = 50 * time.Millisecond
+
+	s.InjectFault(airbytetest.Fault{Path: "/api/v1/health", Latency: 200 * time.Millisecond, Times: 1})
+	err := c.CheckConnectivity()
+	connErr := &api.ConnectivityError{}
+	if !errors.As(err, &connErr) || connErr.Kind != api.ConnectivityTimeout {
+		t.Errorf("CheckConnectivity() error = %v, want a %s connectivity error", err, api.ConnectivityTimeout)
+	}
+
+	// Latency below the timeout only delays the response.
+	s.InjectFault(airbytetest.Fault{Path: "/api/v1/health", Latency: 10 * time.Millisecond, Times: 1})
+	err = c.CheckConnectivity()
+	if err != nil {
+		t.Errorf("CheckConnectivity() error = %v", err)
+	}
+}
+
+func TestFaultServerError(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	s.InjectFault(airbytetest.Fault{
+		Path:       "/api/v1/sources/create",
+		StatusCode: http.StatusInternalServerError,
+		Body:       `{"message":"Internal Server Error: temporal unavailable","exceptionClassName":"io.temporal.TemporalException"}`,
+		Times:      1,
+	})
+	_, err := c.CreateStripeSource(newStripeSource(s.WorkspaceId()))
+	apiErr := &api.Error{}
+	if !errors.As(err, &apiErr) || !apiErr.IsServerError() {
+		t.Fatalf("CreateStripeSource() error = %v, want a server error", err)
+	}
+	if !strings.Contains(err.Error(), "temporal unavailable") || !strings.Contains(err.Error(), "TemporalException") {
+		t.Errorf("CreateStripeSource() error = %q, want the message and exception class", err.Error())
+	}
+
+	s.InjectFault(airbytetest.Fault{
+		Path:       "/api/v1/health",
+		StatusCode: http.StatusBadGateway,
+		Times:      1,
+	})
+	err = c.CheckConnectivity()
+	connErr := &api.ConnectivityError{}
+	if !errors.As(err, &connErr) || connErr.Kind != api.ConnectivityUnavailable {
+		t.Errorf("CheckConnectivity() error = %v, want a %s connectivity error", err, api.ConnectivityUnavailable)
+	}
+}
+
+func TestFaultMalformedJSON(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+
+	created, err := c.CreateStripeSource(newStripeSource(s.WorkspaceId()))
+	if err != nil {
+		t.Fatalf("CreateStripeSource() error = %v", err)
+	}
+
+	s.InjectFault(airbytetest.Fault{Path: "/api/v1/sources/get", Body: `{"sourceId": "`, Times: 1})
+	_, err = c.ReadStripeSource(created.SourceId)
+	if err == nil {
+		t.Error("ReadStripeSource() error = nil, want an error for a malformed response")
+	}
+
+	// Error bodies which are not JSON are kept as a snippet.
+	s.InjectFault(airbytetest.Fault{
+		Path:       "/api/v1/sources/get",
+		StatusCode: http.StatusNotFound,
+		Body:       "<html>Not Found</html>",
+		Times:      1,
+	})
+	_, err = c.ReadStripeSource(created.SourceId)
+	apiErr := &api.Error{}
+	if !errors.As(err, &apiErr) || apiErr.BodySnippet != "<html>Not Found</html>" {
+		t.Errorf("ReadStripeSource() error = %v, want the body snippet", err)
+	}
+
+	// Long ones are cut without splitting multi-byte characters.
+	s.InjectFault(airbytetest.Fault{
+		Path:       "/api/v1/sources/get",
+		StatusCode: http.StatusNotFound,
+		Body:       "x" + strings.Repeat("é", 200),
+		Times:      1,
+	})
+	_, err = c.ReadStripeSource(created.SourceId)
+	if !errors.As(err, &apiErr) || !utf8.ValidString(apiErr.BodySnippet) || !strings.HasSuffix(apiErr.BodySnippet, "é...") {
+		t.Errorf("ReadStripeSource() error = %v, want a valid UTF-8 snippet cut after a character", err)
+	}
+}
+
+func TestFaultMaskedSecrets(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+	c := newTestClient(t, s)
+	s.SetMaskSecrets(true)
+
+	created, err := c.CreateStripeSource(newStripeSource(s.WorkspaceId()))
+	if err != nil {
+		t.Fatalf("CreateStripeSource() error = %v", err)
+	}
+	if created.ConnectionConfiguration.ClientSecret != airbytetest.SecretMask {
+		t.Errorf("CreateStripeSource() client_secret = %q, want it masked", created.ConnectionConfiguration.ClientSecret)
+	}
+
+	read, err := c.ReadStripeSource(created.SourceId)
+	if err != nil {
+		t.Fatalf("ReadStripeSource() error = %v", err)
+	}
+	if read.ConnectionConfiguration.ClientSecret != airbytetest.SecretMask {
+		t.Errorf("ReadStripeSource() client_secret = %q, want it masked", read.ConnectionConfiguration.ClientSecret)
+	}
+	if read.ConnectionConfiguration.AccountId != "acct_123" {
+		t.Errorf("ReadStripeSource() account_id = %q, want it unmasked", read.ConnectionConfiguration.AccountId)
+	}
+
+	// Airbyte keeps the stored secret when the mask is sent back.
+	_, err = c.UpdateStripeSource(read)
+	if err != nil {
+		t.Fatalf("UpdateStripeSource() error = %v", err)
+	}
+	stored, _ := s.Source(created.SourceId)
+	config, _ := stored["connectionConfiguration"].(map[string]interface{})
+	if config["client_secret"] != "sk_test_secret" {
+		t.Errorf("stored client_secret = %v, want the original secret", config["client_secret"])
+	}
+}
+
+func TestRequireFeature(t *testing.T) {
+	s := airbytetest.NewServer()
+	defer s.Close()
+
+	version, err := newTestClient(t, s).ServerVersion()
+	if err != nil || version.String() != airbytetest.DefaultVersion {
+		t.Errorf("ServerVersion() = %s, %v, want %s", version, err, airbytetest.DefaultVersion)
+	}
+
+	s.SetVersion("0.40.0")
+	err = newTestClient(t, s).RequireFeature(api.FeatureConnectionGeography)
+	if err == nil || !strings.Contains(err.Error(), "0.40.19") {
+		t.Errorf("RequireFeature() error = %v, want an error naming the minimum version", err)
+	}
+
+	// Without a known version, the API itself gets to reject requests.
+	s.SetVersion("")
+	c := newTestClient(t, s)
+	_, err = c.ServerVersion()
+	if err == nil {
+		t.Error("ServerVersion() error = nil, want an error without deployment metadata")
+	}
+	err = c.RequireFeature(api.FeatureConnectionGeography)
+	if err != nil {
+		t.Errorf("RequireFeature() error = %v, want nil for an unknown version", err)
+	}
+}
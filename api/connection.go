@@ -2,7 +2,9 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 type ConnectionResourceID struct {
@@ -10,16 +12,55 @@ type ConnectionResourceID struct {
 }
 
 type ConnectionResource struct {
-	Name          string           `json:"name"`
-	SourceID      string           `json:"sourceId,omitempty"`
-	DestinationID string           `json:"destinationId,omitempty"`
-	ConnectionID  string           `json:"connectionId,omitempty"`
-	SyncCatalog   interface{}      `json:"syncCatalog"`
-	Status        string           `json:"status"`
-	ScheduleType  string           `json:"scheduleType"`
-	ScheduleData  ConnScheduleData `json:"scheduleData"`
+	Name                         string           `json:"name"`
+	SourceID                     string           `json:"sourceId,omitempty"`
+	DestinationID                string           `json:"destinationId,omitempty"`
+	ConnectionID                 string           `json:"connectionId,omitempty"`
+	SyncCatalog                  *ConnSyncCatalog `json:"syncCatalog,omitempty"`
+	Status                       string           `json:"status"`
+	ScheduleType                 string           `json:"scheduleType"`
+	ScheduleData                 ConnScheduleData `json:"scheduleData"`
+	NamespaceDefinition          string           `json:"namespaceDefinition,omitempty"`
+	NamespaceFormat              string           `json:"namespaceFormat,omitempty"`
+	Prefix                       string           `json:"prefix,omitempty"`
+	OperationIDs                 []string         `json:"operationIds,omitempty"`
+	Geography                    string           `json:"geography,omitempty"`
+	NonBreakingChangesPreference string           `json:"nonBreakingChangesPreference,omitempty"`
+	NotifySchemaChanges          *bool            `json:"notifySchemaChanges,omitempty"`
 	//OperatorConfiguration connOperatorConfig `json:"operator_configuration"`
 }
+
+type ConnSyncCatalog struct {
+	Streams []ConnSyncCatalogStream `json:"streams"`
+}
+
+type ConnSyncCatalogStream struct {
+	Stream ConnAirbyteStream `json:"stream"`
+	Config ConnStreamConfig  `json:"config"`
+}
+
+type ConnAirbyteStream struct {
+	Name                    string          `json:"name"`
+	Namespace               string          `json:"namespace,omitempty"`
+	JsonSchema              json.RawMessage `json:"jsonSchema,omitempty"`
+	SupportedSyncModes      []string        `json:"supportedSyncModes,omitempty"`
+	SourceDefinedCursor     bool            `json:"sourceDefinedCursor,omitempty"`
+	DefaultCursorField      []string        `json:"defaultCursorField,omitempty"`
+	SourceDefinedPrimaryKey [][]string      `json:"sourceDefinedPrimaryKey,omitempty"`
+}
+
+type ConnStreamConfig struct {
+	SyncMode              string          `json:"syncMode"`
+	DestinationSyncMode   string          `json:"destinationSyncMode"`
+	CursorField           []string        `json:"cursorField,omitempty"`
+	PrimaryKey            [][]string      `json:"primaryKey,omitempty"`
+	AliasName             string          `json:"aliasName,omitempty"`
+	Selected              bool            `json:"selected"`
+	Suggested             bool            `json:"suggested,omitempty"`
+	FieldSelectionEnabled bool            `json:"fieldSelectionEnabled,omitempty"`
+	SelectedFields        json.RawMessage `json:"selectedFields,omitempty"`
+}
+
 type ConnScheduleData struct {
 	BasicSchedule ConnScheduleDataBasicSchedule `json:"basicSchedule,omitempty"`
 	Cron          ConnScheduleDataCron          `json:"cron,omitempty"`
@@ -41,53 +82,32 @@ type DiscoverSourceSchemaCatalog struct {
 }
 
 func (c *Client) CreateConnectionResource(payload ConnectionResource) (ConnectionResource, error) {
-	// logger := fwhelpers.GetLogger()
-
-	discover_payload := DiscoverSourceSchemaCatalog{
-		SourceID:     payload.SourceID,
-		DisableCache: true,
-	}
-	catalog := map[string]interface{}{}
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/discover_schema"
-	body, err := json.Marshal(discover_payload)
-	if err != nil {
+	// Backends without schema discovery pick the streams by name themselves,
+	// which is checked on the created connection.
+	wanted := payload.SyncCatalog
+	discovered, err := c.DiscoverSourceSchema(payload.SourceID, true)
+	switch {
+	case errors.Is(err, ErrUnsupportedOperation):
+	case err != nil:
 		return ConnectionResource{}, err
-	}
-
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
-	if err != nil {
-		return ConnectionResource{}, err
-	}
-
-	err = json.Unmarshal(b, &catalog)
-	if err != nil {
-		return ConnectionResource{}, err
-	}
-	jobInfo := catalog["jobInfo"].(map[string]interface{})
-	if !jobInfo["succeeded"].(bool) {
-		return ConnectionResource{}, fmt.Errorf("failed to get source schema catalog")
-	}
-	if statusCode < 200 || statusCode > 299 {
-		msg, err := c.getAPIError(b)
+	case payload.SyncCatalog == nil:
+		payload.SyncCatalog = discovered.Catalog
+		wanted = nil
+	default:
+		payload.SyncCatalog, err = ConfigureSyncCatalog(*discovered.Catalog, *payload.SyncCatalog)
 		if err != nil {
 			return ConnectionResource{}, err
-		} else {
-			return ConnectionResource{}, fmt.Errorf(msg)
 		}
+		wanted = nil
 	}
 
-	payload.SyncCatalog = catalog["catalog"]
-
-	method = "POST"
-	url = c.Host + "/api/v1/connections/create"
-	body, err = json.Marshal(payload)
+	operation := "connections/create"
+	body, err := json.Marshal(payload)
 	if err != nil {
 		return ConnectionResource{}, err
 	}
 
-	b, statusCode, _, _, err = c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return ConnectionResource{}, err
 	}
@@ -95,29 +115,24 @@ func (c *Client) CreateConnectionResource(payload ConnectionResource) (Connectio
 	connection := ConnectionResource{}
 	if statusCode >= 200 && statusCode <= 299 {
 		err = json.Unmarshal(b, &connection)
-		return connection, err
-	} else {
-		msg, err := c.getAPIError(b)
 		if err != nil {
 			return connection, err
-		} else {
-			return connection, fmt.Errorf(msg)
 		}
+		return connection, checkSyncCatalog(connection, wanted)
+	} else {
+		return connection, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadConnectionResource(connectionId string) (ConnectionResource, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/connections/get"
+	operation := "connections/get"
 	sId := ConnectionResourceID{connectionId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return ConnectionResource{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return ConnectionResource{}, err
 	}
@@ -127,63 +142,37 @@ func (c *Client) ReadConnectionResource(connectionId string) (ConnectionResource
 		err = json.Unmarshal(b, &connection)
 		return connection, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return connection, err
-		} else {
-			return connection, fmt.Errorf(msg)
-		}
+		return connection, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateConnectionResource(payload ConnectionResource) (ConnectionResource, error) {
-	// logger := fwhelpers.GetLogger()
-
-	discover_payload := DiscoverSourceSchemaCatalog{
-		SourceID:     payload.SourceID,
-		DisableCache: true,
-	}
-	catalog := map[string]interface{}{}
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/discover_schema"
-	body, err := json.Marshal(discover_payload)
-	if err != nil {
-		return ConnectionResource{}, err
-	}
-
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
-	if err != nil {
-		return ConnectionResource{}, err
-	}
-
-	err = json.Unmarshal(b, &catalog)
-	if err != nil {
-		return ConnectionResource{}, err
-	}
-	jobInfo := catalog["jobInfo"].(map[string]interface{})
-	if !jobInfo["succeeded"].(bool) {
-		return ConnectionResource{}, fmt.Errorf("failed to get source schema catalog")
-	}
-	if statusCode < 200 || statusCode > 299 {
-		msg, err := c.getAPIError(b)
-		if err != nil {
+	// Only touch the catalog when streams are configured explicitly.
+	// Backends without schema discovery pick the streams by name themselves,
+	// which is checked on the updated connection.
+	var wanted *ConnSyncCatalog
+	if payload.SyncCatalog != nil {
+		discovered, err := c.DiscoverSourceSchema(payload.SourceID, true)
+		switch {
+		case errors.Is(err, ErrUnsupportedOperation):
+			wanted = payload.SyncCatalog
+		case err != nil:
 			return ConnectionResource{}, err
-		} else {
-			return ConnectionResource{}, fmt.Errorf(msg)
+		default:
+			payload.SyncCatalog, err = ConfigureSyncCatalog(*discovered.Catalog, *payload.SyncCatalog)
+			if err != nil {
+				return ConnectionResource{}, err
+			}
 		}
 	}
 
-	// payload.SyncCatalog = catalog["catalog"]
-
-	method = "POST"
-	url = c.Host + "/api/v1/connections/update"
-	body, err = json.Marshal(payload)
+	operation := "connections/update"
+	body, err := json.Marshal(payload)
 	if err != nil {
 		return ConnectionResource{}, err
 	}
 
-	b, statusCode, _, _, err = c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return ConnectionResource{}, err
 	}
@@ -191,29 +180,24 @@ func (c *Client) UpdateConnectionResource(payload ConnectionResource) (Connectio
 	connection := ConnectionResource{}
 	if statusCode >= 200 && statusCode <= 299 {
 		err = json.Unmarshal(b, &connection)
-		return connection, err
-	} else {
-		msg, err := c.getAPIError(b)
 		if err != nil {
-			return ConnectionResource{}, err
-		} else {
-			return connection, fmt.Errorf(msg)
+			return connection, err
 		}
+		return connection, checkSyncCatalog(connection, wanted)
+	} else {
+		return connection, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteConnectionResource(connectionId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/connections/delete"
+	operation := "connections/delete"
 	sId := ConnectionResourceID{connectionId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -221,11 +205,89 @@ func (c *Client) DeleteConnectionResource(connectionId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
+		return c.getAPIError(statusCode, b)
+	}
+}
+
+// ConfigureSyncCatalog applies the stream configurations of the wanted
+// catalog on top of the discovered catalog. Discovered streams which are
+// not wanted get deselected, and wanted streams which were not discovered
+// are reported as an error.
+func ConfigureSyncCatalog(discovered ConnSyncCatalog, wanted ConnSyncCatalog) (*ConnSyncCatalog, error) {
+	configured := ConnSyncCatalog{Streams: []ConnSyncCatalogStream{}}
+	found := make([]bool, len(wanted.Streams))
+
+	for _, d := range discovered.Streams {
+		d.Config.Selected = false
+
+		for i, w := range wanted.Streams {
+			if d.Stream.Name != w.Stream.Name || d.Stream.Namespace != w.Stream.Namespace {
+				continue
+			}
+			found[i] = true
+
+			d.Config.Selected = true
+			if w.Config.SyncMode != "" {
+				d.Config.SyncMode = w.Config.SyncMode
+			}
+			if w.Config.DestinationSyncMode != "" {
+				d.Config.DestinationSyncMode = w.Config.DestinationSyncMode
+			}
+			if w.Config.CursorField != nil {
+				d.Config.CursorField = w.Config.CursorField
+			}
+			if w.Config.PrimaryKey != nil {
+				d.Config.PrimaryKey = w.Config.PrimaryKey
+			}
+			if w.Config.AliasName != "" {
+				d.Config.AliasName = w.Config.AliasName
+			}
+			break
+		}
+
+		configured.Streams = append(configured.Streams, d)
+	}
+
+	for i, w := range wanted.Streams {
+		if !found[i] {
+			return nil, fmt.Errorf("stream %s was not found in the source schema catalog", w.Stream.Name)
+		}
+	}
+
+	return &configured, nil
+}
+
+// Checks that every wanted stream is part of the catalog of the
+// connection, for backends which configure the catalog without schema
+// discovery and skip streams they don't know. A nil wanted catalog
+// is not checked.
+func checkSyncCatalog(connection ConnectionResource, wanted *ConnSyncCatalog) error {
+	if wanted == nil {
+		return nil
+	}
+
+	missing := []string{}
+	for _, w := range wanted.Streams {
+		found := false
+		if connection.SyncCatalog != nil {
+			for _, s := range connection.SyncCatalog.Streams {
+				if s.Config.Selected && s.Stream.Name == w.Stream.Name && s.Stream.Namespace == w.Stream.Namespace {
+					found = true
+					break
+				}
+			}
 		}
+		if !found {
+			missing = append(missing, w.Stream.Name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
 	}
+
+	return fmt.Errorf(
+		"the sync catalog of connection %s could not be configured, streams %s are not part of it. "+
+			"Without schema discovery the streams are matched by name, check them against the source streams",
+		connection.ConnectionID, strings.Join(missing, ", "),
+	)
 }
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type DestinationLocalCSVID struct {
 	DestinationId string `json:"destinationId"`
@@ -27,16 +24,13 @@ type DestinationDelimiterConfigModel struct {
 }
 
 func (c *Client) CreateLocalCSVDestination(payload DestinationLocalCSV) (DestinationLocalCSV, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/destinations/create"
+	operation := "destinations/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return DestinationLocalCSV{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return DestinationLocalCSV{}, err
 	}
@@ -46,27 +40,19 @@ func (c *Client) CreateLocalCSVDestination(payload DestinationLocalCSV) (Destina
 		err = json.Unmarshal(b, &destination)
 		return destination, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return destination, err
-		} else {
-			return destination, fmt.Errorf(msg)
-		}
+		return destination, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadLocalCSVDestination(sourceId string) (DestinationLocalCSV, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/destinations/get"
+	operation := "destinations/get"
 	sId := DestinationLocalCSVID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return DestinationLocalCSV{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return DestinationLocalCSV{}, err
 	}
@@ -76,26 +62,18 @@ func (c *Client) ReadLocalCSVDestination(sourceId string) (DestinationLocalCSV,
 		err = json.Unmarshal(b, &destination)
 		return destination, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return destination, err
-		} else {
-			return destination, fmt.Errorf(msg)
-		}
+		return destination, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateLocalCSVDestination(payload DestinationLocalCSV) (DestinationLocalCSV, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/destinations/update"
+	operation := "destinations/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return DestinationLocalCSV{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return DestinationLocalCSV{}, err
 	}
@@ -105,27 +83,19 @@ func (c *Client) UpdateLocalCSVDestination(payload DestinationLocalCSV) (Destina
 		err = json.Unmarshal(b, &destination)
 		return destination, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return destination, err
-		} else {
-			return destination, fmt.Errorf(msg)
-		}
+		return destination, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteLocalCSVDestination(destinationId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/destinations/delete"
+	operation := "destinations/delete"
 	sId := DestinationLocalCSVID{destinationId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -133,11 +103,6 @@ func (c *Client) DeleteLocalCSVDestination(destinationId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -3,9 +3,15 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// Maximum length of a non-JSON error body kept in errors.
+const errorBodySnippetLength = 256
+
 type APIError struct {
 	Message            string                    `json:"message"`
 	ExceptionClassName string                    `json:"exceptionClassName"`
@@ -19,13 +25,152 @@ type APIErrorValidationError struct {
 	Message      string `json:"message"`
 }
 
-func (c *Client) getAPIError(body []byte) (string, error) {
-	apiErr := APIError{}
-	err := json.Unmarshal(body, &apiErr)
+// Error is returned for every API call which fails with a
+// non-2xx response.
+type Error struct {
+	APIError
+
+	StatusCode int
+
+	// Start of the response body, when it is not an Airbyte error.
+	BodySnippet string
+}
+
+// IsUserError reports whether the request itself was rejected,
+// e.g. due to an invalid configuration, so that retrying won't help.
+func (e *Error) IsUserError() bool {
+	return e.StatusCode >= 400 && e.StatusCode <= 499
+}
+
+// IsServerError reports whether Airbyte failed to handle a valid request.
+func (e *Error) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+func (e *Error) Error() string {
+	status := fmt.Sprintf("HTTP %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+
+	if e.BodySnippet != "" || (e.Message == "" && len(e.ValidationErrors) == 0) {
+		msg := fmt.Sprintf(
+			"unexpected response from Airbyte API (%s), "+
+				"the provider host may not point to an Airbyte API", status,
+		)
+		if e.BodySnippet != "" {
+			msg += ": " + e.BodySnippet
+		}
+		return msg
+	}
+
+	kind := "Airbyte API error"
+	if e.IsUserError() {
+		kind = "invalid request"
+	} else if e.IsServerError() {
+		kind = "Airbyte server error"
+	}
+
+	details := status
+	if e.ExceptionClassName != "" {
+		details += ", " + e.ExceptionClassName
+	}
+
+	// Drop the serialized request which the message ends with.
+	message := strings.TrimSpace(strings.Split(e.Message, "at [Source:")[0])
+	msg := fmt.Sprintf("%s: %s (%s)", kind, message, details)
+
+	for _, v := range e.ValidationErrors {
+		msg += "\n  - "
+		if v.PropertyPath != "" {
+			msg += attributePath(v.PropertyPath) + ": "
+		}
+		msg += v.Message
+		if v.InvalidValue != "" {
+			msg += fmt.Sprintf(" (got %q)", v.InvalidValue)
+		}
+	}
+
+	return msg
+}
+
+func (c *Client) getAPIError(statusCode int, body []byte) error {
+	apiErr := &Error{StatusCode: statusCode}
+
+	err := json.Unmarshal(body, &apiErr.APIError)
 	if err != nil {
-		return "", fmt.Errorf("content type mismatch or invalid provider api host or path")
-	} else {
-		slices := strings.Split(apiErr.Message, "at [Source:")
-		return strings.TrimSpace(slices[0]), nil
+		apiErr.BodySnippet = truncate(strings.TrimSpace(string(body)), errorBodySnippetLength)
+		return apiErr
+	}
+
+	if len(apiErr.ValidationErrors) == 0 {
+		apiErr.ValidationErrors = schemaValidationErrors(apiErr.Message)
+
+		// Those are listed one by one, drop them and the schema dump
+		// from the message.
+		if len(apiErr.ValidationErrors) > 0 {
+			apiErr.Message = strings.TrimSpace(strings.Split(apiErr.Message, "Errors:")[0])
+		}
+	}
+
+	return apiErr
+}
+
+// Extracts the JSON schema validation errors which Airbyte lists in the
+// message of rejected connector configurations, like
+// "Errors: $.start_date: does not match the regex pattern ..., $.client_secret: is missing".
+func schemaValidationErrors(message string) []APIErrorValidationError {
+	start := strings.Index(message, "$.")
+	if start < 0 {
+		return nil
+	}
+	line := strings.SplitN(message[start:], "\n", 2)[0]
+
+	validationErrors := []APIErrorValidationError{}
+	for _, e := range strings.Split(line, ", $.") {
+		e = strings.TrimPrefix(e, "$.")
+		parts := strings.SplitN(e, ": ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		validationErrors = append(validationErrors, APIErrorValidationError{
+			PropertyPath: "connectionConfiguration." + parts[0],
+			Message:      strings.TrimSpace(parts[1]),
+		})
+	}
+
+	return validationErrors
+}
+
+// Maps an API property path to the matching resource attribute path,
+// e.g. connectionConfiguration.start_date to connection_configuration.start_date.
+func attributePath(propertyPath string) string {
+	propertyPath = strings.TrimPrefix(propertyPath, "$.")
+
+	parts := strings.Split(propertyPath, ".")
+	for i, part := range parts {
+		snake := strings.Builder{}
+		for j, r := range part {
+			if unicode.IsUpper(r) {
+				if j > 0 {
+					snake.WriteRune('_')
+				}
+				r = unicode.ToLower(r)
+			}
+			snake.WriteRune(r)
+		}
+		parts[i] = snake.String()
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Returns s cut to at most n bytes followed by "...", or s itself when it
+// is short enough. The cut steps back to the start of a rune, so that no
+// multi-byte character is split.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
 	}
+	return s[:n] + "..."
 }
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"syscall"
+)
+
+type HealthCheckRead struct {
+	Available bool `json:"available"`
+}
+
+// Kinds of connectivity problems told apart by CheckConnectivity.
+const (
+	ConnectivityDNS               = "dns"
+	ConnectivityConnectionRefused = "connection_refused"
+	ConnectivityTimeout           = "timeout"
+	ConnectivityTLS               = "tls"
+	ConnectivityUnauthorized      = "unauthorized"
+	ConnectivityNotAirbyte        = "not_airbyte"
+	ConnectivityUnavailable       = "unavailable"
+	ConnectivityUnknown           = "unknown"
+)
+
+// ConnectivityError describes why the Airbyte API can't be used,
+// with a hint on what to check.
+type ConnectivityError struct {
+	Kind string
+	Host string
+	Err  error
+}
+
+func (e *ConnectivityError) Error() string {
+	hint := ""
+	switch e.Kind {
+	case ConnectivityDNS:
+		hint = "unable to resolve the host name, check the provider host for typos"
+	case ConnectivityConnectionRefused:
+		hint = "connection refused, check that Airbyte is running and listens on the port of the provider host"
+	case ConnectivityTimeout:
+		hint = "no response in time, check firewalls and proxy settings between the provider and Airbyte"
+	case ConnectivityTLS:
+		hint = "TLS handshake failed, check the host scheme and the ca_cert_file, ca_cert_pem or tls_min_version settings"
+	case ConnectivityUnauthorized:
+		hint = "credentials were rejected, check the provider username and password"
+	case ConnectivityNotAirbyte:
+		hint = "the host responds, but not like an Airbyte API, check that the provider host points to the Airbyte server and not e.g. the webapp or a proxy login page"
+	case ConnectivityUnavailable:
+		hint = "Airbyte reports itself as unavailable, check the health of the Airbyte server"
+	default:
+		hint = "unable to use the Airbyte API"
+	}
+
+	return fmt.Sprintf("%s: %s.\n%s", e.Host, hint, e.Err.Error())
+}
+
+func (e *ConnectivityError) Unwrap() error {
+	return e.Err
+}
+
+// CheckHealth reports whether the Airbyte server is up.
+func (c *Client) CheckHealth() error {
+	operation := "health"
+
+	b, statusCode, err := c.call(operation, nil)
+	if err != nil {
+		return err
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		return c.getAPIError(statusCode, b)
+	}
+
+	health := HealthCheckRead{}
+	err = json.Unmarshal(b, &health)
+	if err != nil {
+		return c.getAPIError(statusCode, b)
+	}
+	if !health.Available {
+		return &ConnectivityError{
+			Kind: ConnectivityUnavailable,
+			Host: c.Host,
+			Err:  fmt.Errorf("health check returned available: false"),
+		}
+	}
+
+	return nil
+}
+
+// CheckCredentials verifies the credentials with a cheap authenticated call.
+func (c *Client) CheckCredentials() error {
+	operation := "workspaces/list"
+
+	b, statusCode, err := c.call(operation, []byte("{}"))
+	if err != nil {
+		return err
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		return c.getAPIError(statusCode, b)
+	}
+
+	var workspaces struct {
+		Workspaces []json.RawMessage `json:"workspaces"`
+	}
+	err = json.Unmarshal(b, &workspaces)
+	if err != nil {
+		return c.getAPIError(statusCode, b)
+	}
+
+	return nil
+}
+
+// CheckConnectivity calls the health endpoint and checks the credentials.
+// Failures are returned as ConnectivityError.
+func (c *Client) CheckConnectivity() error {
+	err := c.CheckHealth()
+	if err == nil {
+		err = c.CheckCredentials()
+	}
+	if err == nil {
+		return nil
+	}
+
+	connErr := &ConnectivityError{}
+	if errors.As(err, &connErr) {
+		return err
+	}
+
+	return &ConnectivityError{
+		Kind: connectivityKind(err),
+		Host: c.Host,
+		Err:  err,
+	}
+}
+
+// Classifies the error of a failed API call.
+func connectivityKind(err error) string {
+	apiErr := &Error{}
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == 401 || apiErr.StatusCode == 403:
+			return ConnectivityUnauthorized
+		case apiErr.BodySnippet != "" || apiErr.StatusCode == 404:
+			return ConnectivityNotAirbyte
+		case apiErr.StatusCode == 502 || apiErr.StatusCode == 503 || apiErr.StatusCode == 504:
+			return ConnectivityUnavailable
+		default:
+			return ConnectivityUnknown
+		}
+	}
+
+	dnsErr := &net.DNSError{}
+	if errors.As(err, &dnsErr) {
+		return ConnectivityDNS
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ConnectivityConnectionRefused
+	}
+
+	var (
+		unknownAuthority x509.UnknownAuthorityError
+		hostname         x509.HostnameError
+		invalid          x509.CertificateInvalidError
+		recordHeader     tls.RecordHeaderError
+	)
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostname) ||
+		errors.As(err, &invalid) || errors.As(err, &recordHeader) {
+		return ConnectivityTLS
+	}
+	// Not typed by net/http, happens for https hosts serving plain HTTP.
+	if strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") {
+		return ConnectivityTLS
+	}
+
+	netErr := net.Error(nil)
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ConnectivityTimeout
+	}
+
+	return ConnectivityUnknown
+}
@@ -2,35 +2,114 @@ package api
 
 import (
 	"bytes"
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
+// Client talks to the Airbyte API.
+// It is safe for concurrent use and is meant to be shared, so that
+// connections get pooled. Its fields must not change once created.
 type Client struct {
 	HTTPClient    *http.Client
 	Host          string
 	Username      string
 	Password      string
 	Authorization string
+
+	// Upper bound for source schema discovery, including polling.
+	DiscoverTimeout time.Duration
+
+	// Verbosity of the request logs.
+	LogLevel LogLevel
+
+	// API the requests are made against.
+	Backend Backend
+
+	// Server version, looked up once on first use.
+	versionOnce sync.Once
+	version     Version
+	versionErr  error
+}
+
+// ClientConfig holds everything needed to create a Client.
+type ClientConfig struct {
+	Host     string
+	Username string
+	Password string
+
+	TLS TLSConfig
+
+	// Proxy for all requests, with a http, https or socks5 scheme.
+	// When empty, the proxy environment variables are used.
+	ProxyURL string
+
+	LogLevel LogLevel
+
+	// API backend, either config (default) or public.
+	Backend string
 }
 
-func NewClient(host string, username string, password string) (*Client, error) {
+func NewClient(config ClientConfig) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+
+	host, err := configureTransport(transport, config.Host, config.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := newBackend(config.Backend)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := config.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+
 	c := Client{
 		HTTPClient: &http.Client{
-			Timeout: time.Duration(10) * time.Second,
+			Transport: transport,
+			Timeout:   time.Duration(10) * time.Second,
 		},
-		Host:          host,
-		Username:      username,
-		Password:      password,
-		Authorization: "",
+		Host:     host,
+		Username: config.Username,
+		Password: config.Password,
+
+		DiscoverTimeout: defaultDiscoverTimeout,
+		LogLevel:        config.LogLevel,
+		Backend:         backend,
 	}
+	c.Authorization = c.genBasicAuthToken()
+
 	return &c, nil
 }
 
 func (c *Client) doRequest(method string, url string, body []byte, headers map[string]string) ([]byte, int, string, map[string][]string, error) {
+	return c.doRequestWithClient(c.HTTPClient, method, url, body, headers)
+}
+
+// Same as doRequest, but with a request timeout other than the client default.
+// Used by long running calls like source schema discovery.
+func (c *Client) doRequestWithTimeout(method string, url string, body []byte, headers map[string]string, timeout time.Duration) ([]byte, int, string, map[string][]string, error) {
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = timeout
+	return c.doRequestWithClient(&httpClient, method, url, body, headers)
+}
+
+func (c *Client) doRequestWithClient(httpClient *http.Client, method string, url string, body []byte, headers map[string]string) ([]byte, int, string, map[string][]string, error) {
 	payload := bytes.NewBuffer(body)
 
 	req, err := http.NewRequest(method, url, payload)
@@ -38,32 +117,60 @@ func (c *Client) doRequest(method string, url string, body []byte, headers map[s
 		return nil, 500, "500 Internal Server Error", nil, err
 	}
 
-	if c.Authorization == "" {
-		c.Authorization = c.genBasicAuthToken()
-	}
+	requestId := newRequestId()
 
-	req.Header.Add("Authorization", c.Authorization)
+	if c.Authorization != "" {
+		req.Header.Add("Authorization", c.Authorization)
+	}
 	req.Header.Add("Accept", "*/*")
 	req.Header.Add("User-Agent", "PCT")
 	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("X-Request-Id", requestId)
 
 	for header, value := range headers {
 		req.Header.Add(header, value)
 	}
 
-	res, err := c.HTTPClient.Do(req)
+	l := requestLog{
+		method:     method,
+		path:       req.URL.Path,
+		requestId:  requestId,
+		reqHeaders: req.Header,
+		reqBody:    body,
+	}
+	start := time.Now()
+
+	res, err := httpClient.Do(req)
 	if err != nil {
+		l.latency, l.err = time.Since(start), err
+		c.logRequest(l)
 		return nil, 500, "500 Internal Server Error", nil, err
 	}
+	defer res.Body.Close()
+
 	b, err := io.ReadAll(res.Body)
+	l.latency, l.status, l.resBody, l.err = time.Since(start), res.StatusCode, b, err
+	if id := res.Header.Get("X-Request-Id"); id != "" {
+		l.requestId = id
+	}
+	c.logRequest(l)
 	if err != nil {
 		return nil, 500, "500 Internal Server Error", nil, err
 	}
 
-	defer res.Body.Close()
 	return b, res.StatusCode, res.Status, res.Header, nil
 }
 
+// Returns a random ID to correlate a request with server logs.
+func newRequestId() string {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
 func (c *Client) genBasicAuthToken() string {
 	if c.Username == "" || c.Password == "" {
 		return ""
@@ -0,0 +1,163 @@
+package api
+
+import "encoding/json"
+
+type WorkspaceIdRequestBody struct {
+	WorkspaceId string `json:"workspaceId"`
+}
+
+type SourceIdRequestBody struct {
+	SourceId string `json:"sourceId"`
+}
+
+type DestinationIdRequestBody struct {
+	DestinationId string `json:"destinationId"`
+}
+
+type SourceReadList struct {
+	Sources []SourceRead `json:"sources"`
+}
+
+// SourceRead holds the connector independent fields of a source.
+type SourceRead struct {
+	SourceId           string `json:"sourceId"`
+	Name               string `json:"name"`
+	SourceDefinitionId string `json:"sourceDefinitionId"`
+	WorkspaceId        string `json:"workspaceId"`
+}
+
+type DestinationReadList struct {
+	Destinations []DestinationRead `json:"destinations"`
+}
+
+// DestinationRead holds the connector independent fields of a destination.
+type DestinationRead struct {
+	DestinationId           string `json:"destinationId"`
+	Name                    string `json:"name"`
+	DestinationDefinitionId string `json:"destinationDefinitionId"`
+	WorkspaceId             string `json:"workspaceId"`
+}
+
+type WebBackendConnectionReadList struct {
+	Connections []WebBackendConnectionListItem `json:"connections"`
+}
+
+type WebBackendConnectionListItem struct {
+	ConnectionId string                 `json:"connectionId"`
+	Name         string                 `json:"name"`
+	Source       SourceSnippetRead      `json:"source"`
+	Destination  DestinationSnippetRead `json:"destination"`
+}
+
+type SourceSnippetRead struct {
+	SourceId string `json:"sourceId"`
+}
+
+type DestinationSnippetRead struct {
+	DestinationId string `json:"destinationId"`
+}
+
+func (c *Client) ReadSource(sourceId string) (SourceRead, error) {
+	operation := "sources/get"
+	body, err := json.Marshal(SourceIdRequestBody{sourceId})
+	if err != nil {
+		return SourceRead{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return SourceRead{}, err
+	}
+
+	source := SourceRead{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &source)
+		return source, err
+	} else {
+		return source, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) ReadDestination(destinationId string) (DestinationRead, error) {
+	operation := "destinations/get"
+	body, err := json.Marshal(DestinationIdRequestBody{destinationId})
+	if err != nil {
+		return DestinationRead{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return DestinationRead{}, err
+	}
+
+	destination := DestinationRead{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &destination)
+		return destination, err
+	} else {
+		return destination, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) ListSources(workspaceId string) ([]SourceRead, error) {
+	operation := "sources/list"
+	body, err := json.Marshal(WorkspaceIdRequestBody{workspaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return nil, err
+	}
+
+	list := SourceReadList{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &list)
+		return list.Sources, err
+	} else {
+		return nil, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) ListDestinations(workspaceId string) ([]DestinationRead, error) {
+	operation := "destinations/list"
+	body, err := json.Marshal(WorkspaceIdRequestBody{workspaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return nil, err
+	}
+
+	list := DestinationReadList{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &list)
+		return list.Destinations, err
+	} else {
+		return nil, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) ListWebBackendConnections(workspaceId string) ([]WebBackendConnectionListItem, error) {
+	operation := "web_backend/connections/list"
+	body, err := json.Marshal(WorkspaceIdRequestBody{workspaceId})
+	if err != nil {
+		return nil, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return nil, err
+	}
+
+	list := WebBackendConnectionReadList{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &list)
+		return list.Connections, err
+	} else {
+		return nil, c.getAPIError(statusCode, b)
+	}
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+)
+
+type LogLevel int
+
+const (
+	LogLevelOff LogLevel = iota
+	LogLevelError
+	LogLevelInfo
+	LogLevelDebug
+	LogLevelTrace
+)
+
+var logLevelNames = map[string]LogLevel{
+	"off":   LogLevelOff,
+	"error": LogLevelError,
+	"info":  LogLevelInfo,
+	"debug": LogLevelDebug,
+	"trace": LogLevelTrace,
+}
+
+func (l LogLevel) String() string {
+	for name, level := range logLevelNames {
+		if level == l {
+			return name
+		}
+	}
+	return strconv.Itoa(int(l))
+}
+
+// ParseLogLevel parses one of off, error, info, debug or trace.
+// An empty level is off.
+func ParseLogLevel(level string) (LogLevel, error) {
+	if level == "" {
+		return LogLevelOff, nil
+	}
+	l, ok := logLevelNames[strings.ToLower(strings.TrimSpace(level))]
+	if !ok {
+		return LogLevelOff, fmt.Errorf("invalid log level %q, expected one of off, error, info, debug or trace", level)
+	}
+	return l, nil
+}
+
+// Placeholder for redacted values.
+const redacted = "REDACTED"
+
+// Maximum length of a logged response body which is not JSON.
+const logBodySnippetLength = 512
+
+// Maximum number of logged exception stack frames.
+const logExceptionStackFrames = 10
+
+// Field names holding secrets. Besides these, any field with
+// secret, password or token in its name is redacted too.
+var sensitiveFields = map[string]bool{
+	"api_key":             true,
+	"apikey":              true,
+	"client_secret":       true,
+	"client_key":          true,
+	"private_key":         true,
+	"secret_key":          true,
+	"credentials_json":    true,
+	"service_account_key": true,
+	"authorization":       true,
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	if sensitiveFields[name] {
+		return true
+	}
+	for _, s := range []string{"secret", "password", "token"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the JSON body with all sensitive field values replaced.
+// Bodies which are not JSON are reduced to a short snippet.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	err := json.Unmarshal(body, &v)
+	if err != nil {
+		return truncate(string(body), logBodySnippetLength)
+	}
+
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if isSensitiveField(k) {
+				if _, isString := val.(string); isString || val == nil {
+					t[k] = redacted
+					continue
+				}
+			}
+			t[k] = redactValue(val)
+		}
+		return t
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// Returns the headers with sensitive values replaced.
+func redactHeaders(headers http.Header) string {
+	names := []string{}
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := []string{}
+	for _, name := range names {
+		value := strings.Join(headers[name], ",")
+		if isSensitiveField(name) || strings.EqualFold(name, "Cookie") {
+			value = redacted
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", name, value))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Details of a single API call to log.
+type requestLog struct {
+	method    string
+	path      string
+	requestId string
+	status    int
+	latency   time.Duration
+	err       error
+
+	reqHeaders http.Header
+	reqBody    []byte
+	resBody    []byte
+}
+
+// Logs an API call as logfmt style key value pairs.
+// Failed calls are logged from the error level, all calls with their
+// method, path, status and latency from the info level, with request
+// IDs and exception stacks from the debug level, and redacted headers and bodies from the
+// trace level.
+func (c *Client) logRequest(l requestLog) {
+	failed := l.err != nil || l.status < 200 || l.status > 299
+
+	level := LogLevelInfo
+	if failed {
+		level = LogLevelError
+	}
+	if c.LogLevel < level {
+		return
+	}
+
+	fields := []string{
+		"level=" + level.String(),
+		`msg="airbyte api request"`,
+		"method=" + l.method,
+		"path=" + strconv.Quote(l.path),
+	}
+	if failed || c.LogLevel >= LogLevelDebug {
+		fields = append(fields, "request_id="+l.requestId)
+	}
+	fields = append(fields, "latency_ms="+strconv.FormatInt(l.latency.Milliseconds(), 10))
+	if l.err != nil {
+		fields = append(fields, "error="+strconv.Quote(l.err.Error()))
+	} else {
+		fields = append(fields, "status="+strconv.Itoa(l.status))
+	}
+	if failed && len(l.resBody) > 0 {
+		apiErr := APIError{}
+		if json.Unmarshal(l.resBody, &apiErr) == nil {
+			if apiErr.ExceptionClassName != "" {
+				fields = append(fields, "exception_class="+apiErr.ExceptionClassName)
+			}
+			if len(apiErr.ExceptionStack) > 0 && c.LogLevel >= LogLevelDebug {
+				fields = append(fields, "exception_stack="+strconv.Quote(exceptionStack(apiErr.ExceptionStack)))
+			}
+		}
+	}
+	if c.LogLevel >= LogLevelTrace {
+		fields = append(fields,
+			"request_headers="+strconv.Quote(redactHeaders(l.reqHeaders)),
+			"request_body="+strconv.Quote(redactBody(l.reqBody)),
+			"response_body="+strconv.Quote(redactBody(l.resBody)),
+		)
+	}
+
+	fwhelpers.GetLogger().Println(strings.Join(fields, " "))
+}
+
+// Returns the top frames of an Airbyte exception stack on one line.
+func exceptionStack(frames []string) string {
+	stack := []string{}
+	for i, frame := range frames {
+		if i == logExceptionStackFrames {
+			stack = append(stack, fmt.Sprintf("... %d more", len(frames)-i))
+			break
+		}
+		stack = append(stack, strings.TrimSpace(frame))
+	}
+	return strings.Join(stack, " | ")
+}
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourceAmplitudeID struct {
 	SourceId string `json:"sourceId"`
@@ -18,22 +15,22 @@ type SourceAmplitude struct {
 }
 
 type SourceAmplitudeConnConfig struct {
-	StartDate  string `json:"start_date"`
-	DataRegion string `json:"data_region,omitempty"`
-	SecretKey  string `json:"secret_key"`
-	ApiKey     string `json:"api_key"`
+	StartDate                 string  `json:"start_date"`
+	DataRegion                *string `json:"data_region,omitempty"`
+	RequestTimeRange          *int    `json:"request_time_range,omitempty"`
+	ActiveUsersGroupByCountry *bool   `json:"active_users_group_by_country,omitempty"`
+	SecretKey                 string  `json:"secret_key"`
+	ApiKey                    string  `json:"api_key"`
 }
 
 func (c *Client) CreateAmplitudeSource(payload SourceAmplitude) (SourceAmplitude, error) {
-	// logger := fwhelpers.GetLogger()
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceAmplitude{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceAmplitude{}, err
 	}
@@ -42,27 +39,19 @@ func (c *Client) CreateAmplitudeSource(payload SourceAmplitude) (SourceAmplitude
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadAmplitudeSource(sourceId string) (SourceAmplitude, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourceAmplitudeID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourceAmplitude{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceAmplitude{}, err
 	}
@@ -72,26 +61,18 @@ func (c *Client) ReadAmplitudeSource(sourceId string) (SourceAmplitude, error) {
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateAmplitudeSource(payload SourceAmplitude) (SourceAmplitude, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceAmplitude{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceAmplitude{}, err
 	}
@@ -101,27 +82,19 @@ func (c *Client) UpdateAmplitudeSource(payload SourceAmplitude) (SourceAmplitude
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteAmplitudeSource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourceAmplitudeID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -129,11 +102,6 @@ func (c *Client) DeleteAmplitudeSource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -1,9 +1,6 @@
 package api
 
-// import (
-// 	"encoding/json"
-// 	"fmt"
-// )
+// import "encoding/json"
 
 // type SourceFakerID struct {
 // 	SourceId string `json:"sourceId"`
@@ -27,14 +24,13 @@ package api
 // func (c *Client) CreateSource(payload SourceFaker) (SourceFaker, error) {
 // 	// logger := fwhelpers.GetLogger()
 
-// 	method := "POST"
-// 	url := c.Host + "/api/v1/sources/create"
+// 	operation := "sources/create"
 // 	body, err := json.Marshal(payload)
 // 	if err != nil {
 // 		return SourceFaker{}, err
 // 	}
 
-// 	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+// 	b, statusCode, err := c.call(operation, body)
 // 	if err != nil {
 // 		return SourceFaker{}, err
 // 	}
@@ -44,27 +40,21 @@ package api
 // 		err = json.Unmarshal(b, &source)
 // 		return source, err
 // 	} else {
-// 		msg, err := c.getAPIError(b)
-// 		if err != nil {
-// 			return source, err
-// 		} else {
-// 			return source, fmt.Errorf(msg)
-// 		}
+// 		return source, c.getAPIError(statusCode, b)
 // 	}
 // }
 
 // func (c *Client) ReadSource(sourceId string) (SourceFaker, error) {
 // 	// logger := fwhelpers.GetLogger()
 
-// 	method := "POST"
-// 	url := c.Host + "/api/v1/sources/get"
+// 	operation := "sources/get"
 // 	sId := SourceFakerID{sourceId}
 // 	body, err := json.Marshal(sId)
 // 	if err != nil {
 // 		return SourceFaker{}, err
 // 	}
 
-// 	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+// 	b, statusCode, err := c.call(operation, body)
 // 	if err != nil {
 // 		return SourceFaker{}, err
 // 	}
@@ -74,26 +64,20 @@ package api
 // 		err = json.Unmarshal(b, &source)
 // 		return source, err
 // 	} else {
-// 		msg, err := c.getAPIError(b)
-// 		if err != nil {
-// 			return source, err
-// 		} else {
-// 			return source, fmt.Errorf(msg)
-// 		}
+// 		return source, c.getAPIError(statusCode, b)
 // 	}
 // }
 
 // func (c *Client) UpdateSource(payload SourceFaker) (SourceFaker, error) {
 // 	// logger := fwhelpers.GetLogger()
 
-// 	method := "POST"
-// 	url := c.Host + "/api/v1/sources/update"
+// 	operation := "sources/update"
 // 	body, err := json.Marshal(payload)
 // 	if err != nil {
 // 		return SourceFaker{}, err
 // 	}
 
-// 	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+// 	b, statusCode, err := c.call(operation, body)
 // 	if err != nil {
 // 		return SourceFaker{}, err
 // 	}
@@ -103,27 +87,21 @@ package api
 // 		err = json.Unmarshal(b, &source)
 // 		return source, err
 // 	} else {
-// 		msg, err := c.getAPIError(b)
-// 		if err != nil {
-// 			return source, err
-// 		} else {
-// 			return source, fmt.Errorf(msg)
-// 		}
+// 		return source, c.getAPIError(statusCode, b)
 // 	}
 // }
 
 // func (c *Client) DeleteSource(sourceId string) error {
 // 	// logger := fwhelpers.GetLogger()
 
-// 	method := "POST"
-// 	url := c.Host + "/api/v1/sources/delete"
+// 	operation := "sources/delete"
 // 	sId := SourceFakerID{sourceId}
 // 	body, err := json.Marshal(sId)
 // 	if err != nil {
 // 		return err
 // 	}
 
-// 	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+// 	b, statusCode, err := c.call(operation, body)
 // 	if err != nil {
 // 		return err
 // 	}
@@ -131,11 +109,6 @@ package api
 // 	if statusCode >= 200 && statusCode <= 299 {
 // 		return nil
 // 	} else {
-// 		msg, err := c.getAPIError(b)
-// 		if err != nil {
-// 			return err
-// 		} else {
-// 			return fmt.Errorf(msg)
-// 		}
+// 		return c.getAPIError(statusCode, b)
 // 	}
 // }
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourceFreshdeskID struct {
 	SourceId string `json:"sourceId"`
@@ -18,22 +15,21 @@ type SourceFreshdesk struct {
 }
 
 type SourceFreshdeskConnConfig struct {
-	Domain            string `json:"domain"`
-	StartDate         string `json:"start_date"`
-	ApiKey            string `json:"api_key"`
-	RequestsPerMinute int    `json:"requests_per_minute,omitempty"`
+	Domain               string `json:"domain"`
+	StartDate            string `json:"start_date"`
+	ApiKey               string `json:"api_key"`
+	RequestsPerMinute    *int   `json:"requests_per_minute,omitempty"`
+	LookbackWindowInDays *int   `json:"lookback_window_in_days,omitempty"`
 }
 
 func (c *Client) CreateFreshdeskSource(payload SourceFreshdesk) (SourceFreshdesk, error) {
-	// logger := fwhelpers.GetLogger()
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceFreshdesk{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceFreshdesk{}, err
 	}
@@ -42,27 +38,19 @@ func (c *Client) CreateFreshdeskSource(payload SourceFreshdesk) (SourceFreshdesk
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadFreshdeskSource(sourceId string) (SourceFreshdesk, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourceFreshdeskID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourceFreshdesk{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceFreshdesk{}, err
 	}
@@ -72,26 +60,18 @@ func (c *Client) ReadFreshdeskSource(sourceId string) (SourceFreshdesk, error) {
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateFreshdeskSource(payload SourceFreshdesk) (SourceFreshdesk, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceFreshdesk{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceFreshdesk{}, err
 	}
@@ -101,27 +81,19 @@ func (c *Client) UpdateFreshdeskSource(payload SourceFreshdesk) (SourceFreshdesk
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteFreshdeskSource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourceFreshdeskID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -129,11 +101,6 @@ func (c *Client) DeleteFreshdeskSource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourceHubspotID struct {
 	SourceId string `json:"sourceId"`
@@ -31,15 +28,13 @@ type HubspotCredConfigModel struct {
 }
 
 func (c *Client) CreateHubspotSource(payload SourceHubspot) (SourceHubspot, error) {
-	// logger := fwhelpers.GetLogger()
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceHubspot{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceHubspot{}, err
 	}
@@ -48,27 +43,19 @@ func (c *Client) CreateHubspotSource(payload SourceHubspot) (SourceHubspot, erro
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadHubspotSource(sourceId string) (SourceHubspot, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourceHubspotID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourceHubspot{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceHubspot{}, err
 	}
@@ -78,26 +65,18 @@ func (c *Client) ReadHubspotSource(sourceId string) (SourceHubspot, error) {
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateHubspotSource(payload SourceHubspot) (SourceHubspot, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceHubspot{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceHubspot{}, err
 	}
@@ -107,27 +86,19 @@ func (c *Client) UpdateHubspotSource(payload SourceHubspot) (SourceHubspot, erro
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteHubspotSource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourceHubspotID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -135,11 +106,6 @@ func (c *Client) DeleteHubspotSource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
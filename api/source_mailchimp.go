@@ -0,0 +1,113 @@
+// Code generated by connectorgen from specs/source_mailchimp.json. DO NOT EDIT.
+
+package api
+
+import "encoding/json"
+
+type SourceMailchimpID struct {
+	SourceId string `json:"sourceId"`
+}
+
+type SourceMailchimp struct {
+	Name                    string                    `json:"name"`
+	SourceId                string                    `json:"sourceId,omitempty"`
+	SourceDefinitionId      string                    `json:"sourceDefinitionId,omitempty"`
+	WorkspaceId             string                    `json:"workspaceId,omitempty"`
+	ConnectionConfiguration SourceMailchimpConnConfig `json:"connectionConfiguration"`
+}
+
+type SourceMailchimpConnConfig struct {
+	StartDate   *string                     `json:"start_date,omitempty"`
+	Credentials *SourceMailchimpCredentials `json:"credentials,omitempty"`
+}
+
+type SourceMailchimpCredentials struct {
+	AuthType     string  `json:"auth_type"`
+	AccessToken  string  `json:"access_token,omitempty"`
+	ClientId     *string `json:"client_id,omitempty"`
+	ClientSecret *string `json:"client_secret,omitempty"`
+	Apikey       string  `json:"apikey,omitempty"`
+}
+
+func (c *Client) CreateMailchimpSource(payload SourceMailchimp) (SourceMailchimp, error) {
+	operation := "sources/create"
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return SourceMailchimp{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return SourceMailchimp{}, err
+	}
+	source := SourceMailchimp{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &source)
+		return source, err
+	} else {
+		return source, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) ReadMailchimpSource(sourceId string) (SourceMailchimp, error) {
+	operation := "sources/get"
+	id := SourceMailchimpID{sourceId}
+	body, err := json.Marshal(id)
+	if err != nil {
+		return SourceMailchimp{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return SourceMailchimp{}, err
+	}
+
+	source := SourceMailchimp{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &source)
+		return source, err
+	} else {
+		return source, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) UpdateMailchimpSource(payload SourceMailchimp) (SourceMailchimp, error) {
+	operation := "sources/update"
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return SourceMailchimp{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return SourceMailchimp{}, err
+	}
+
+	source := SourceMailchimp{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &source)
+		return source, err
+	} else {
+		return source, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) DeleteMailchimpSource(sourceId string) error {
+	operation := "sources/delete"
+	id := SourceMailchimpID{sourceId}
+	body, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return err
+	}
+
+	if statusCode >= 200 && statusCode <= 299 {
+		return nil
+	} else {
+		return c.getAPIError(statusCode, b)
+	}
+}
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourcePipedriveID struct {
 	SourceId string `json:"sourceId"`
@@ -23,21 +20,21 @@ type SourcePipedriveConnConfig struct {
 }
 
 type SourcePipedriveAuthConfigModel struct {
-	AuthType string `json:"auth_type"`
-	ApiToken string `json:"api_token"`
+	AuthType     string `json:"auth_type"`
+	ApiToken     string `json:"api_token,omitempty"`
+	ClientId     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
 }
 
 func (c *Client) CreatePipedriveSource(payload SourcePipedrive) (SourcePipedrive, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourcePipedrive{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourcePipedrive{}, err
 	}
@@ -47,27 +44,19 @@ func (c *Client) CreatePipedriveSource(payload SourcePipedrive) (SourcePipedrive
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadPipedriveSource(sourceId string) (SourcePipedrive, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourcePipedriveID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourcePipedrive{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourcePipedrive{}, err
 	}
@@ -77,26 +66,18 @@ func (c *Client) ReadPipedriveSource(sourceId string) (SourcePipedrive, error) {
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdatePipedriveSource(payload SourcePipedrive) (SourcePipedrive, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourcePipedrive{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourcePipedrive{}, err
 	}
@@ -106,27 +87,19 @@ func (c *Client) UpdatePipedriveSource(payload SourcePipedrive) (SourcePipedrive
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeletePipedriveSource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourcePipedriveID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -134,11 +107,6 @@ func (c *Client) DeletePipedriveSource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// Number of connector log lines kept in discovery errors.
+	discoverErrorLogLines = 50
+
+	// Discovery runs a connector container, which takes far longer
+	// than the default client timeout allows for.
+	discoverRequestTimeout = 2 * time.Minute
+	discoverPollInterval   = 10 * time.Second
+	defaultDiscoverTimeout = 30 * time.Minute
+)
+
+type SourceDiscoverSchemaRead struct {
+	Catalog   *ConnSyncCatalog   `json:"catalog"`
+	CatalogId string             `json:"catalogId,omitempty"`
+	JobInfo   SynchronousJobRead `json:"jobInfo"`
+}
+
+type SynchronousJobRead struct {
+	Id            string            `json:"id"`
+	ConfigType    string            `json:"configType"`
+	ConfigId      string            `json:"configId,omitempty"`
+	CreatedAt     int64             `json:"createdAt"`
+	EndedAt       int64             `json:"endedAt"`
+	Succeeded     bool              `json:"succeeded"`
+	Logs          *JobLogRead       `json:"logs,omitempty"`
+	FailureReason *JobFailureReason `json:"failureReason,omitempty"`
+}
+
+type JobLogRead struct {
+	LogLines []string `json:"logLines"`
+}
+
+type JobFailureReason struct {
+	FailureOrigin   string `json:"failureOrigin,omitempty"`
+	FailureType     string `json:"failureType,omitempty"`
+	ExternalMessage string `json:"externalMessage,omitempty"`
+	InternalMessage string `json:"internalMessage,omitempty"`
+	Stacktrace      string `json:"stacktrace,omitempty"`
+	Retryable       bool   `json:"retryable,omitempty"`
+	Timestamp       int64  `json:"timestamp,omitempty"`
+}
+
+// DiscoverSourceSchema returns the catalog of a source.
+// Airbyte keeps running the discover job when a request times out and
+// caches its catalog, so on timeouts the cached catalog is polled for
+// until the client DiscoverTimeout is reached. When the cache is
+// disabled, only a catalog discovered by a job started since the first
+// request is returned, so that an older cached one isn't taken for it.
+func (c *Client) DiscoverSourceSchema(sourceId string, disableCache bool) (SourceDiscoverSchemaRead, error) {
+	timeout := c.DiscoverTimeout
+	if timeout <= 0 {
+		timeout = defaultDiscoverTimeout
+	}
+	started := time.Now()
+	deadline := started.Add(timeout)
+
+	polling := false
+	for {
+		discovered, err := c.discoverSourceSchema(sourceId, disableCache && !polling)
+
+		var netErr net.Error
+		timedOut := err != nil && errors.As(err, &netErr) && netErr.Timeout()
+		if !timedOut {
+			// A cached catalog older than the first request comes from
+			// an earlier job, so keep waiting for the running one.
+			stale := err == nil && polling && disableCache &&
+				jobTime(discovered.JobInfo.CreatedAt).Before(started.Truncate(time.Second))
+			if !stale {
+				return discovered, err
+			}
+		}
+		if time.Now().Add(discoverPollInterval).After(deadline) {
+			return SourceDiscoverSchemaRead{}, fmt.Errorf(
+				"failed to get source schema catalog: discovery did not finish within %s", timeout,
+			)
+		}
+
+		// The running job fills the cache, so don't start another one.
+		polling = true
+		time.Sleep(discoverPollInterval)
+	}
+}
+
+// Returns the time of a job timestamp, which Airbyte gives in either
+// seconds or milliseconds since the epoch depending on the endpoint.
+func jobTime(epoch int64) time.Time {
+	if epoch > 1e12 {
+		return time.UnixMilli(epoch)
+	}
+	return time.Unix(epoch, 0)
+}
+
+func (c *Client) discoverSourceSchema(sourceId string, disableCache bool) (SourceDiscoverSchemaRead, error) {
+	operation := "sources/discover_schema"
+	payload := DiscoverSourceSchemaCatalog{
+		SourceID:     sourceId,
+		DisableCache: disableCache,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return SourceDiscoverSchemaRead{}, err
+	}
+
+	b, statusCode, err := c.callWithTimeout(operation, body, discoverRequestTimeout)
+	if err != nil {
+		return SourceDiscoverSchemaRead{}, err
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		return SourceDiscoverSchemaRead{}, c.getAPIError(statusCode, b)
+	}
+
+	discovered := SourceDiscoverSchemaRead{}
+	err = json.Unmarshal(b, &discovered)
+	if err != nil {
+		return SourceDiscoverSchemaRead{}, fmt.Errorf("failed to decode source schema catalog: %s", err.Error())
+	}
+	if !discovered.JobInfo.Succeeded {
+		return discovered, discoverJobError(discovered.JobInfo)
+	}
+	if discovered.Catalog == nil {
+		return discovered, fmt.Errorf("failed to get source schema catalog: no catalog returned")
+	}
+
+	return discovered, nil
+}
+
+// Builds an error out of a failed discover job, with the failure
+// reason and the tail of the connector logs.
+func discoverJobError(jobInfo SynchronousJobRead) error {
+	msg := "failed to get source schema catalog"
+
+	if reason := jobInfo.FailureReason; reason != nil {
+		if reason.ExternalMessage != "" {
+			msg += ": " + reason.ExternalMessage
+		} else if reason.InternalMessage != "" {
+			msg += ": " + reason.InternalMessage
+		}
+		if reason.FailureType != "" {
+			msg += fmt.Sprintf(" (%s)", reason.FailureType)
+		}
+	}
+	if jobInfo.Id != "" {
+		msg += fmt.Sprintf("\nJob ID: %s", jobInfo.Id)
+	}
+
+	if jobInfo.Logs != nil && len(jobInfo.Logs.LogLines) > 0 {
+		lines := jobInfo.Logs.LogLines
+		if len(lines) > discoverErrorLogLines {
+			lines = lines[len(lines)-discoverErrorLogLines:]
+		}
+		msg += "\nConnector logs:\n" + strings.Join(lines, "\n")
+	}
+
+	return fmt.Errorf("%s", msg)
+}
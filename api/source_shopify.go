@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourceShopifyID struct {
 	SourceId string `json:"sourceId"`
@@ -21,8 +18,9 @@ type SourceShopifyConnConfig struct {
 	StartDate string `json:"start_date"`
 	Shop      string `json:"shop"`
 
-	Credentials ShopifyCredConfigModel `cty:"credentials"`
+	Credentials ShopifyCredConfigModel `json:"credentials"`
 }
+
 type ShopifyCredConfigModel struct {
 	AuthMethod   string `json:"auth_method"`
 	ApiPassword  string `json:"api_password,omitempty"`
@@ -32,15 +30,13 @@ type ShopifyCredConfigModel struct {
 }
 
 func (c *Client) CreateShopifySource(payload SourceShopify) (SourceShopify, error) {
-	// logger := fwhelpers.GetLogger()
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceShopify{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceShopify{}, err
 	}
@@ -49,27 +45,19 @@ func (c *Client) CreateShopifySource(payload SourceShopify) (SourceShopify, erro
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadShopifySource(sourceId string) (SourceShopify, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourceShopifyID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourceShopify{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceShopify{}, err
 	}
@@ -79,26 +67,18 @@ func (c *Client) ReadShopifySource(sourceId string) (SourceShopify, error) {
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateShopifySource(payload SourceShopify) (SourceShopify, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceShopify{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceShopify{}, err
 	}
@@ -108,27 +88,19 @@ func (c *Client) UpdateShopifySource(payload SourceShopify) (SourceShopify, erro
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteShopifySource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourceShopifyID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -136,11 +108,6 @@ func (c *Client) DeleteShopifySource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourceStripeID struct {
 	SourceId string `json:"sourceId"`
@@ -19,22 +16,22 @@ type SourceStripe struct {
 
 type SourceStripeConnConfig struct {
 	StartDate          string `json:"start_date"`
-	LookbackWindowDays int    `json:"lookback_window_days,omitempty"`
-	SliceRange         int    `json:"slice_range,omitempty"`
+	LookbackWindowDays *int   `json:"lookback_window_days,omitempty"`
+	SliceRange         *int   `json:"slice_range,omitempty"`
+	NumWorkers         *int   `json:"num_workers,omitempty"`
+	CallRateLimit      *int   `json:"call_rate_limit,omitempty"`
 	ClientSecret       string `json:"client_secret"`
 	AccountId          string `json:"account_id"`
 }
 
 func (c *Client) CreateStripeSource(payload SourceStripe) (SourceStripe, error) {
-	// logger := fwhelpers.GetLogger()
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceStripe{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceStripe{}, err
 	}
@@ -43,27 +40,19 @@ func (c *Client) CreateStripeSource(payload SourceStripe) (SourceStripe, error)
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadStripeSource(sourceId string) (SourceStripe, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourceStripeID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourceStripe{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceStripe{}, err
 	}
@@ -73,26 +62,18 @@ func (c *Client) ReadStripeSource(sourceId string) (SourceStripe, error) {
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateStripeSource(payload SourceStripe) (SourceStripe, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceStripe{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceStripe{}, err
 	}
@@ -102,27 +83,19 @@ func (c *Client) UpdateStripeSource(payload SourceStripe) (SourceStripe, error)
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteStripeSource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourceStripeID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -130,11 +103,6 @@ func (c *Client) DeleteStripeSource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -1,9 +1,6 @@
 package api
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "encoding/json"
 
 type SourceZendeskSupportID struct {
 	SourceId string `json:"sourceId"`
@@ -20,27 +17,28 @@ type SourceZendeskSupport struct {
 type SourceZendeskSupportConnConfig struct {
 	StartDate        string                              `json:"start_date"`
 	Subdomain        string                              `json:"subdomain,omitempty"`
-	IgnorePagination bool                                `json:"ignore_pagination"`
+	IgnorePagination *bool                               `json:"ignore_pagination,omitempty"`
+	NumWorkers       *int                                `json:"num_workers,omitempty"`
 	Credentials      SourceZendeskSupportCredConfigModel `json:"credentials"`
 }
 
 type SourceZendeskSupportCredConfigModel struct {
-	Credentials string `json:"credentials"`
-	ApiToken    string `json:"api_token,omitempty"`
-	Email       string `json:"email,omitempty"`
-	AccessToken string `json:"access_token,omitempty"`
+	Credentials  string `json:"credentials"`
+	ApiToken     string `json:"api_token,omitempty"`
+	Email        string `json:"email,omitempty"`
+	AccessToken  string `json:"access_token,omitempty"`
+	ClientId     string `json:"client_id,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
 }
 
 func (c *Client) CreateZendeskSupportSource(payload SourceZendeskSupport) (SourceZendeskSupport, error) {
-	// logger := fwhelpers.GetLogger()
-	method := "POST"
-	url := c.Host + "/api/v1/sources/create"
+	operation := "sources/create"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceZendeskSupport{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceZendeskSupport{}, err
 	}
@@ -49,27 +47,19 @@ func (c *Client) CreateZendeskSupportSource(payload SourceZendeskSupport) (Sourc
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) ReadZendeskSupportSource(sourceId string) (SourceZendeskSupport, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/get"
+	operation := "sources/get"
 	sId := SourceZendeskSupportID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return SourceZendeskSupport{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceZendeskSupport{}, err
 	}
@@ -79,26 +69,18 @@ func (c *Client) ReadZendeskSupportSource(sourceId string) (SourceZendeskSupport
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) UpdateZendeskSupportSource(payload SourceZendeskSupport) (SourceZendeskSupport, error) {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/update"
+	operation := "sources/update"
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return SourceZendeskSupport{}, err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return SourceZendeskSupport{}, err
 	}
@@ -108,27 +90,19 @@ func (c *Client) UpdateZendeskSupportSource(payload SourceZendeskSupport) (Sourc
 		err = json.Unmarshal(b, &source)
 		return source, err
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return source, err
-		} else {
-			return source, fmt.Errorf(msg)
-		}
+		return source, c.getAPIError(statusCode, b)
 	}
 }
 
 func (c *Client) DeleteZendeskSupportSource(sourceId string) error {
-	// logger := fwhelpers.GetLogger()
-
-	method := "POST"
-	url := c.Host + "/api/v1/sources/delete"
+	operation := "sources/delete"
 	sId := SourceZendeskSupportID{sourceId}
 	body, err := json.Marshal(sId)
 	if err != nil {
 		return err
 	}
 
-	b, statusCode, _, _, err := c.doRequest(method, url, body, nil)
+	b, statusCode, err := c.call(operation, body)
 	if err != nil {
 		return err
 	}
@@ -136,11 +110,6 @@ func (c *Client) DeleteZendeskSupportSource(sourceId string) error {
 	if statusCode >= 200 && statusCode <= 299 {
 		return nil
 	} else {
-		msg, err := c.getAPIError(b)
-		if err != nil {
-			return err
-		} else {
-			return fmt.Errorf(msg)
-		}
+		return c.getAPIError(statusCode, b)
 	}
 }
@@ -0,0 +1,78 @@
+package api
+
+import "encoding/json"
+
+type ConnectionStateID struct {
+	ConnectionId string `json:"connectionId"`
+}
+
+type ConnectionState struct {
+	StateType    string                  `json:"stateType"`
+	ConnectionId string                  `json:"connectionId"`
+	State        json.RawMessage         `json:"state,omitempty"`
+	StreamState  []ConnectionStreamState `json:"streamState,omitempty"`
+	GlobalState  *ConnectionGlobalState  `json:"globalState,omitempty"`
+}
+
+type ConnectionStreamState struct {
+	StreamDescriptor StreamDescriptor `json:"streamDescriptor"`
+	StreamState      json.RawMessage  `json:"streamState,omitempty"`
+}
+
+type StreamDescriptor struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type ConnectionGlobalState struct {
+	SharedState  json.RawMessage         `json:"shared_state,omitempty"`
+	StreamStates []ConnectionStreamState `json:"streamStates"`
+}
+
+type ConnectionStateCreateOrUpdate struct {
+	ConnectionId    string          `json:"connectionId"`
+	ConnectionState ConnectionState `json:"connectionState"`
+}
+
+func (c *Client) ReadConnectionState(connectionId string) (ConnectionState, error) {
+	operation := "state/get"
+	cId := ConnectionStateID{connectionId}
+	body, err := json.Marshal(cId)
+	if err != nil {
+		return ConnectionState{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return ConnectionState{}, err
+	}
+
+	state := ConnectionState{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &state)
+		return state, err
+	} else {
+		return state, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) CreateOrUpdateConnectionState(payload ConnectionStateCreateOrUpdate) (ConnectionState, error) {
+	operation := "state/create_or_update"
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return ConnectionState{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return ConnectionState{}, err
+	}
+
+	state := ConnectionState{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &state)
+		return state, err
+	} else {
+		return state, c.getAPIError(statusCode, b)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSConfig holds the TLS settings for reaching the Airbyte API,
+// e.g. behind a reverse proxy with an internal CA or mutual TLS.
+type TLSConfig struct {
+	// CA bundle to verify the server with, as a file path or PEM.
+	// Both are added to the system roots.
+	CACertFile string
+	CACertPEM  string
+
+	// Client certificate and key for mutual TLS, as file paths or PEM.
+	ClientCertFile string
+	ClientKeyFile  string
+	ClientCertPEM  string
+	ClientKeyPEM   string
+
+	// Minimum TLS version, one of 1.0, 1.1, 1.2 or 1.3.
+	MinVersion string
+
+	// Skip server certificate verification. Only meant for development.
+	InsecureSkipVerify bool
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Builds the TLS client configuration, or returns nil when
+// nothing differs from the defaults.
+func (t TLSConfig) build() (*tls.Config, error) {
+	if t == (TLSConfig{}) {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.MinVersion != "" {
+		version, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid minimum TLS version %q, expected one of 1.0, 1.1, 1.2 or 1.3", t.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if t.CACertFile != "" || t.CACertPEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if t.CACertFile != "" {
+			pem, err := os.ReadFile(t.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read CA certificate file: %s", err.Error())
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no valid certificates found in CA certificate file %s", t.CACertFile)
+			}
+		}
+		if t.CACertPEM != "" {
+			if !pool.AppendCertsFromPEM([]byte(t.CACertPEM)) {
+				return nil, fmt.Errorf("no valid certificates found in CA certificate PEM")
+			}
+		}
+
+		config.RootCAs = pool
+	}
+
+	certPEM, keyPEM := []byte(t.ClientCertPEM), []byte(t.ClientKeyPEM)
+	if t.ClientCertFile != "" {
+		b, err := os.ReadFile(t.ClientCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client certificate file: %s", err.Error())
+		}
+		certPEM = b
+	}
+	if t.ClientKeyFile != "" {
+		b, err := os.ReadFile(t.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client key file: %s", err.Error())
+		}
+		keyPEM = b
+	}
+
+	if len(certPEM) > 0 || len(keyPEM) > 0 {
+		if len(certPEM) == 0 || len(keyPEM) == 0 {
+			return nil, fmt.Errorf("both a client certificate and a client key are required for mutual TLS")
+		}
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %s", err.Error())
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
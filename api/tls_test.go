@@ -0,0 +1,269 @@
+package api_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Certificate authority issuing the server and client certificates.
+type testCA struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{
+		cert:    cert,
+		key:     key,
+		certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+	}
+}
+
+// Issues a certificate for 127.0.0.1 and returns it as PEM with its key.
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+// Starts a TLS server answering health checks, with a certificate
+// issued by the CA. The server config can be adjusted by configure.
+func newTLSServer(t *testing.T, ca *testCA, configure func(*tls.Config)) *httptest.Server {
+	t.Helper()
+
+	certPEM, keyPEM := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"available":true}`))
+	}))
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if configure != nil {
+		configure(s.TLS)
+	}
+	s.StartTLS()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func checkHealth(t *testing.T, host string, config api.TLSConfig) error {
+	t.Helper()
+
+	c, err := api.NewClient(api.ClientConfig{Host: host, TLS: config})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return c.CheckHealth()
+}
+
+func TestTLSCACert(t *testing.T) {
+	ca := newTestCA(t)
+	s := newTLSServer(t, ca, nil)
+
+	tests := []struct {
+		name   string
+		config api.TLSConfig
+	}{
+		{"file", api.TLSConfig{CACertFile: writeFile(t, "ca.pem", ca.certPEM)}},
+		{"pem", api.TLSConfig{CACertPEM: ca.certPEM}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkHealth(t, s.URL, tt.config)
+			if err != nil {
+				t.Errorf("CheckHealth() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestTLSUnknownCA(t *testing.T) {
+	ca := newTestCA(t)
+	s := newTLSServer(t, ca, nil)
+
+	c, err := api.NewClient(api.ClientConfig{Host: s.URL})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	err = c.CheckConnectivity()
+
+	connErr := &api.ConnectivityError{}
+	if !errors.As(err, &connErr) || connErr.Kind != api.ConnectivityTLS {
+		t.Errorf("CheckConnectivity() error = %v, want a %s connectivity error", err, api.ConnectivityTLS)
+	}
+}
+
+func TestTLSInvalidCACert(t *testing.T) {
+	_, err := api.NewClient(api.ClientConfig{
+		Host: "https://127.0.0.1",
+		TLS:  api.TLSConfig{CACertPEM: "not a certificate"},
+	})
+	if err == nil {
+		t.Error("NewClient() error = nil, want an error for an invalid CA PEM")
+	}
+}
+
+func TestTLSClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	s := newTLSServer(t, ca, func(config *tls.Config) {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = x509.NewCertPool()
+		config.ClientCAs.AddCert(ca.cert)
+	})
+	certPEM, keyPEM := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+	t.Run("pem", func(t *testing.T) {
+		err := checkHealth(t, s.URL, api.TLSConfig{
+			CACertPEM:     ca.certPEM,
+			ClientCertPEM: certPEM,
+			ClientKeyPEM:  keyPEM,
+		})
+		if err != nil {
+			t.Errorf("CheckHealth() error = %v", err)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		err := checkHealth(t, s.URL, api.TLSConfig{
+			CACertPEM:      ca.certPEM,
+			ClientCertFile: writeFile(t, "client.pem", certPEM),
+			ClientKeyFile:  writeFile(t, "client.key", keyPEM),
+		})
+		if err != nil {
+			t.Errorf("CheckHealth() error = %v", err)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		err := checkHealth(t, s.URL, api.TLSConfig{CACertPEM: ca.certPEM})
+		if err == nil {
+			t.Error("CheckHealth() error = nil, want an error without a client certificate")
+		}
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		_, err := api.NewClient(api.ClientConfig{
+			Host: s.URL,
+			TLS:  api.TLSConfig{CACertPEM: ca.certPEM, ClientCertPEM: certPEM},
+		})
+		if err == nil {
+			t.Error("NewClient() error = nil, want an error for a client certificate without key")
+		}
+	})
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	ca := newTestCA(t)
+	s := newTLSServer(t, ca, func(config *tls.Config) {
+		config.MaxVersion = tls.VersionTLS12
+	})
+
+	err := checkHealth(t, s.URL, api.TLSConfig{CACertPEM: ca.certPEM, MinVersion: "1.2"})
+	if err != nil {
+		t.Errorf("CheckHealth() with minimum version 1.2 error = %v", err)
+	}
+
+	err = checkHealth(t, s.URL, api.TLSConfig{CACertPEM: ca.certPEM, MinVersion: "1.3"})
+	if err == nil {
+		t.Error("CheckHealth() with minimum version 1.3 error = nil, want a handshake error")
+	}
+
+	_, err = api.NewClient(api.ClientConfig{
+		Host: s.URL,
+		TLS:  api.TLSConfig{MinVersion: "1.4"},
+	})
+	if err == nil {
+		t.Error("NewClient() error = nil, want an error for an unknown minimum version")
+	}
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	ca := newTestCA(t)
+	s := newTLSServer(t, ca, nil)
+
+	err := checkHealth(t, s.URL, api.TLSConfig{InsecureSkipVerify: true})
+	if err != nil {
+		t.Errorf("CheckHealth() error = %v", err)
+	}
+}
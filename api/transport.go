@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Host scheme for reaching the API over a Unix domain socket,
+// e.g. unix:///var/run/airbyte.sock
+const unixSocketScheme = "unix://"
+
+// Requests over a Unix domain socket still need a HTTP host.
+const unixSocketHost = "http://localhost"
+
+// Sets up proxying or Unix domain socket dialing on the transport,
+// and returns the host to build request URLs with.
+func configureTransport(transport *http.Transport, host string, proxyURL string) (string, error) {
+	if strings.HasPrefix(host, unixSocketScheme) {
+		socketPath := strings.TrimPrefix(host, unixSocketScheme)
+		if socketPath == "" {
+			return "", fmt.Errorf("invalid host %q: missing Unix socket path", host)
+		}
+		if proxyURL != "" {
+			return "", fmt.Errorf("a proxy cannot be used with a Unix socket host")
+		}
+
+		dialer := &net.Dialer{}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+
+		return unixSocketHost, nil
+	}
+
+	proxy, err := proxyFunc(proxyURL)
+	if err != nil {
+		return "", err
+	}
+	transport.Proxy = proxy
+
+	return host, nil
+}
+
+// Returns the proxy selection function for the transport.
+// Without a proxy URL, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+// environment variables are used. A proxy URL is used for all
+// requests, except those to hosts matched by NO_PROXY.
+func proxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %s", err.Error())
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be one of http, https or socks5", proxyURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", proxyURL)
+	}
+
+	noProxy := os.Getenv("NO_PROXY")
+	if noProxy == "" {
+		noProxy = os.Getenv("no_proxy")
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypassProxy(req.URL, noProxy) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// Reports whether the NO_PROXY list matches the request host.
+// Entries are host names, domain suffixes (with or without a
+// leading dot), IP addresses or CIDR ranges, optionally with a port.
+// A single * matches all hosts.
+func bypassProxy(reqURL *url.URL, noProxy string) bool {
+	host := strings.ToLower(reqURL.Hostname())
+	port := reqURL.Port()
+
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ip := net.ParseIP(host); ip != nil && ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if host == strings.TrimPrefix(entryHost, ".") {
+			return true
+		}
+		if strings.HasPrefix(entryHost, ".") && strings.HasSuffix(host, entryHost) {
+			return true
+		}
+		if !strings.HasPrefix(entryHost, ".") && strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+)
+
+type DeploymentMetadataRead struct {
+	Id          string `json:"id"`
+	Mode        string `json:"mode"`
+	Version     string `json:"version"`
+	Environment string `json:"environment"`
+}
+
+// Version of an Airbyte server.
+// Development builds have no version number and are considered newer
+// than any release.
+type Version struct {
+	Raw                 string
+	Major, Minor, Patch int
+	Dev                 bool
+}
+
+func (v Version) String() string {
+	return v.Raw
+}
+
+// ParseVersion parses versions like 0.50.33, v0.40.0-alpha or dev.
+func ParseVersion(s string) (Version, error) {
+	v := Version{Raw: s}
+
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if core == "" || core == "dev" {
+		v.Dev = true
+		return v, nil
+	}
+	core = strings.SplitN(core, "-", 2)[0]
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid Airbyte version %q", s)
+	}
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, fmt.Errorf("invalid Airbyte version %q", s)
+		}
+		*numbers[i] = n
+	}
+
+	return v, nil
+}
+
+// AtLeast reports whether the version is the same as or newer than min.
+func (v Version) AtLeast(min Version) bool {
+	if v.Dev {
+		return true
+	}
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch >= min.Patch
+}
+
+// Feature of the Airbyte API which only some server versions have.
+type Feature struct {
+	Name       string
+	MinVersion string
+}
+
+var (
+	FeatureConnectionSchedule = Feature{
+		Name:       "connection schedule_type and schedule_data",
+		MinVersion: "0.40.0",
+	}
+	FeatureConnectionGeography = Feature{
+		Name:       "connection geography",
+		MinVersion: "0.40.19",
+	}
+	FeatureNonBreakingChangesPreference = Feature{
+		Name:       "connection non_breaking_changes_preference and notify_schema_changes",
+		MinVersion: "0.40.26",
+	}
+	FeatureConnectionStateUpdate = Feature{
+		Name:       "connection state overrides",
+		MinVersion: "0.40.15",
+	}
+)
+
+// ServerVersion returns the version of the Airbyte server.
+// It is only looked up once, when first needed, and cached afterwards.
+func (c *Client) ServerVersion() (Version, error) {
+	c.versionOnce.Do(func() {
+		c.version, c.versionErr = c.readServerVersion()
+		if c.versionErr != nil && c.LogLevel >= LogLevelError {
+			fwhelpers.GetLogger().Println(
+				`level=error msg="airbyte server version unknown, version checks skipped" error=` +
+					strconv.Quote(c.versionErr.Error()),
+			)
+		}
+	})
+	return c.version, c.versionErr
+}
+
+func (c *Client) readServerVersion() (Version, error) {
+	operation := "deployment/metadata"
+
+	b, statusCode, err := c.call(operation, nil)
+	if err != nil {
+		return Version{}, err
+	}
+
+	if statusCode < 200 || statusCode > 299 {
+		return Version{}, c.getAPIError(statusCode, b)
+	}
+
+	metadata := DeploymentMetadataRead{}
+	err = json.Unmarshal(b, &metadata)
+	if err != nil {
+		return Version{}, c.getAPIError(statusCode, b)
+	}
+
+	return ParseVersion(metadata.Version)
+}
+
+// RequireFeature fails when the server is too old for the feature.
+// When the server version is unknown, the feature is assumed to exist,
+// so that the API itself gets to reject the request.
+func (c *Client) RequireFeature(f Feature) error {
+	version, err := c.ServerVersion()
+	if err != nil {
+		return nil
+	}
+
+	min, err := ParseVersion(f.MinVersion)
+	if err != nil {
+		return err
+	}
+	if !version.AtLeast(min) {
+		return fmt.Errorf(
+			"%s requires Airbyte %s or newer, but the server at %s runs %s",
+			f.Name, f.MinVersion, c.Host, version,
+		)
+	}
+
+	return nil
+}
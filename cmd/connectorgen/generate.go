@@ -0,0 +1,661 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+// Generates the API and plugin code of one connector.
+type generator struct {
+	// Kind of connector, source or destination.
+	Kind string
+	// Connector name in snake case, e.g. zendesk_support.
+	Name string
+	// Connector title used in descriptions, e.g. Zendesk Support.
+	Title string
+	// Spec file path recorded in the generated file headers.
+	SpecPath string
+
+	Config *field
+}
+
+// Go name of the connector kind, Source or Destination.
+func (g *generator) KindName() string {
+	return goName(g.Kind)
+}
+
+// Go name of the connector, e.g. ZendeskSupport.
+func (g *generator) GoName() string {
+	return goName(g.Name)
+}
+
+// API type of the connector, e.g. SourceZendeskSupport.
+func (g *generator) APIType() string {
+	return g.KindName() + g.GoName()
+}
+
+// Unexported prefix of the plugin identifiers, e.g. sourceZendeskSupport.
+func (g *generator) Prefix() string {
+	return g.Kind + g.GoName()
+}
+
+// Resource type name suffix, e.g. source_zendesk_support.
+func (g *generator) TypeName() string {
+	return g.Kind + "_" + g.Name
+}
+
+// Names of the ID attribute and fields, e.g. source_id and SourceId.
+func (g *generator) IdAttribute() string {
+	return g.Kind + "_id"
+}
+
+func (g *generator) IdField() string {
+	return g.KindName() + "Id"
+}
+
+func (g *generator) DefinitionIdAttribute() string {
+	return g.Kind + "_definition_id"
+}
+
+func (g *generator) DefinitionIdField() string {
+	return g.KindName() + "DefinitionId"
+}
+
+// Whether the configuration has secrets, which get a secrets_hash.
+func (g *generator) HasSecrets() bool {
+	return hasSecrets(g.Config)
+}
+
+func (g *generator) apiType(f *field) string {
+	return g.APIType() + f.TypeName
+}
+
+func (g *generator) modelType(f *field) string {
+	return g.Prefix() + f.TypeName + "Model"
+}
+
+func (g *generator) variantType(f *field, v *variant) string {
+	return g.Prefix() + f.TypeName + v.GoName + "Model"
+}
+
+func (g *generator) funcName(f *field, suffix string) string {
+	return g.Prefix() + f.TypeName + suffix
+}
+
+// Whether the variant has no fields besides the discriminator, in which
+// case it is declared as a bool attribute, as blocks need attributes.
+func (v *variant) isFlag() bool {
+	return len(v.Fields) == 0
+}
+
+// Returns the expression telling whether the variant is set in model m.
+func (v *variant) isSet(m string) string {
+	if v.isFlag() {
+		return fmt.Sprintf("boolValue(%s.%s)", m, v.GoName)
+	}
+	return fmt.Sprintf("%s.%s != nil", m, v.GoName)
+}
+
+// Returns the nested object and oneOf fields, depth first.
+func nestedFields(f *field) []*field {
+	fields := []*field{f}
+	if f.Kind == kindObject {
+		for _, child := range f.Fields {
+			if child.Kind == kindObject || child.Kind == kindOneOf {
+				fields = append(fields, nestedFields(child)...)
+			}
+		}
+	}
+	return fields
+}
+
+func hasSecrets(f *field) bool {
+	if f.Secret {
+		return true
+	}
+	for _, child := range f.Fields {
+		if hasSecrets(child) {
+			return true
+		}
+	}
+	return false
+}
+
+func scalarType(k kind) string {
+	switch k {
+	case kindInt:
+		return "int64"
+	case kindFloat:
+		return "float64"
+	case kindBool:
+		return "bool"
+	default:
+		return "string"
+	}
+}
+
+// Returns the Go type of a field in the model or, within the api
+// package, the API structs.
+func (g *generator) fieldType(f *field, api bool) string {
+	t := ""
+	switch f.Kind {
+	case kindList:
+		return "[]" + scalarType(f.Elem)
+	case kindObject, kindOneOf:
+		if api {
+			t = g.apiType(f)
+		} else {
+			t = g.modelType(f)
+		}
+	default:
+		t = scalarType(f.Kind)
+	}
+	if !f.Required {
+		t = "*" + t
+	}
+	return t
+}
+
+// Whether a oneOf API field is a pointer, which it is when it is
+// optional in any of the variants.
+func unionPointer(u *field, name string) bool {
+	if isScalar(findField(u.Fields, name)) {
+		for _, v := range u.Variants {
+			if vf := findField(v.Fields, name); vf != nil && !vf.Required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isScalar(f *field) bool {
+	return f != nil && f.Kind != kindList && f.Kind != kindObject && f.Kind != kindOneOf
+}
+
+func findField(fields []*field, name string) *field {
+	for _, f := range fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// Writes the struct types of the API file.
+func (g *generator) apiStructs(b *bytes.Buffer) {
+	for _, f := range nestedFields(g.Config) {
+		fmt.Fprintf(b, "type %s struct {\n", g.apiType(f))
+		if f.Kind == kindOneOf {
+			fmt.Fprintf(b, "%s string `json:\"%s\"`\n", goName(f.Discriminator), f.Discriminator)
+			for _, uf := range f.Fields {
+				t := g.fieldType(uf, true)
+				if isScalar(uf) {
+					t = scalarType(uf.Kind)
+					if unionPointer(f, uf.Name) {
+						t = "*" + t
+					}
+				}
+				fmt.Fprintf(b, "%s %s `json:\"%s,omitempty\"`\n", uf.GoName, t, uf.Name)
+			}
+		} else {
+			for _, ff := range f.Fields {
+				tag := ff.Name
+				if !ff.Required || ff.Kind == kindList {
+					tag += ",omitempty"
+				}
+				fmt.Fprintf(b, "%s %s `json:\"%s\"`\n", ff.GoName, g.fieldType(ff, true), tag)
+			}
+		}
+		b.WriteString("}\n\n")
+	}
+}
+
+// Writes the model struct types of the plugin file.
+func (g *generator) modelStructs(b *bytes.Buffer) {
+	for _, f := range nestedFields(g.Config) {
+		if f.Kind == kindOneOf {
+			fmt.Fprintf(b, "type %s struct {\n", g.modelType(f))
+			for _, v := range f.Variants {
+				t := "*" + g.variantType(f, v)
+				if v.isFlag() {
+					t = "*bool"
+				}
+				fmt.Fprintf(b, "%s %s `pctsdk:\"%s,omitempty\"`\n", v.GoName, t, v.Key)
+			}
+			b.WriteString("}\n\n")
+
+			for _, v := range f.Variants {
+				if v.isFlag() {
+					continue
+				}
+				fmt.Fprintf(b, "type %s struct {\n", g.variantType(f, v))
+				for _, vf := range v.Fields {
+					fmt.Fprintf(b, "%s %s `pctsdk:\"%s\"`\n", vf.GoName, g.fieldType(vf, false), vf.Name)
+				}
+				b.WriteString("}\n\n")
+			}
+			g.oneOfVar(b, f)
+			continue
+		}
+
+		fmt.Fprintf(b, "type %s struct {\n", g.modelType(f))
+		for _, ff := range f.Fields {
+			fmt.Fprintf(b, "%s %s `pctsdk:\"%s\"`\n", ff.GoName, g.fieldType(ff, false), ff.Name)
+		}
+		b.WriteString("}\n\n")
+	}
+}
+
+// Writes the oneOf declaration of a field, with the attributes of its
+// variants.
+func (g *generator) oneOfVar(b *bytes.Buffer, f *field) {
+	fmt.Fprintf(b, "// Blocks of %s, told apart by %s.\n", f.Name, f.Discriminator)
+	fmt.Fprintf(b, "var %s = oneOf{\n", g.funcName(f, ""))
+	fmt.Fprintf(b, "Path: %q,\n", f.Path)
+	fmt.Fprintf(b, "Discriminator: %q,\n", f.Discriminator)
+	b.WriteString("Variants: []oneOfVariant{\n")
+	for _, v := range f.Variants {
+		fmt.Fprintf(b, "{\nKey: %q,\nValue: %q,\n", v.Key, v.Value)
+		if !v.isFlag() {
+			b.WriteString("Attributes: map[string]schema.Attribute{\n")
+			for _, vf := range v.Fields {
+				g.attribute(b, vf)
+			}
+			b.WriteString("},\n")
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("},\n}\n\n")
+}
+
+// Writes the schema attribute of a field.
+func (g *generator) attribute(b *bytes.Buffer, f *field) {
+	fmt.Fprintf(b, "%q: ", f.Name)
+	if f.Kind == kindOneOf {
+		fmt.Fprintf(b, "%s.attribute(%s, %t),\n", g.funcName(f, ""), strconv.Quote(attributeDescription(f)), f.Required)
+		return
+	}
+
+	switch f.Kind {
+	case kindString:
+		b.WriteString("&schema.StringAttribute{\n")
+	case kindInt:
+		b.WriteString("&schema.IntAttribute{\n")
+	case kindFloat:
+		b.WriteString("&schema.FloatAttribute{\n")
+	case kindBool:
+		b.WriteString("&schema.BoolAttribute{\n")
+	case kindList:
+		b.WriteString("&schema.ListAttribute{\n")
+	case kindObject:
+		b.WriteString("&schema.MapAttribute{\n")
+	}
+
+	fmt.Fprintf(b, "Description: %s,\n", strconv.Quote(attributeDescription(f)))
+	switch {
+	case f.Required:
+		b.WriteString("Required: true,\n")
+	case hasDefault(f):
+		b.WriteString("Optional: true,\nComputed: true,\n")
+	default:
+		b.WriteString("Optional: true,\n")
+	}
+	if f.Secret {
+		b.WriteString("Sensitive: true,\n")
+	}
+
+	switch f.Kind {
+	case kindList:
+		b.WriteString("NestedAttribute: ")
+		switch f.Elem {
+		case kindInt:
+			b.WriteString("&schema.IntAttribute{")
+		case kindFloat:
+			b.WriteString("&schema.FloatAttribute{")
+		case kindBool:
+			b.WriteString("&schema.BoolAttribute{")
+		default:
+			b.WriteString("&schema.StringAttribute{")
+		}
+		b.WriteString("Required: true},\n")
+	case kindObject:
+		b.WriteString("Attributes: map[string]schema.Attribute{\n")
+		for _, child := range f.Fields {
+			g.attribute(b, child)
+		}
+		b.WriteString("},\n")
+	}
+	b.WriteString("},\n")
+}
+
+func hasDefault(f *field) bool {
+	return f.Default != nil && !f.Secret && isScalar(f)
+}
+
+func attributeDescription(f *field) string {
+	d := f.Description
+	if d == "" {
+		d = strings.ReplaceAll(f.Name, "_", " ")
+	}
+	if len(f.Enum) > 0 {
+		d += ". One of " + strings.Join(f.Enum, ", ")
+	}
+	if hasDefault(f) {
+		d += fmt.Sprintf(". Defaults to %v", f.Default)
+	}
+	if f.Secret {
+		d += ". May be a file:// or env:// secret reference"
+	}
+	return d
+}
+
+// Returns the Go literal of a default value.
+func defaultLiteral(f *field) string {
+	switch f.Kind {
+	case kindString:
+		return strconv.Quote(fmt.Sprint(f.Default))
+	case kindBool:
+		return fmt.Sprint(f.Default)
+	default:
+		return fmt.Sprintf("%s(%v)", scalarType(f.Kind), f.Default)
+	}
+}
+
+// Writes the functions mapping the model to the API body and back,
+// validating the model and listing its secrets.
+func (g *generator) mappingFuncs(b *bytes.Buffer) {
+	for _, f := range nestedFields(g.Config) {
+		if f.Kind == kindOneOf {
+			g.oneOfFuncs(b, f)
+		} else {
+			g.objectFuncs(b, f)
+		}
+	}
+}
+
+func (g *generator) objectFuncs(b *bytes.Buffer, f *field) {
+	model, apiType := g.modelType(f), "api."+g.apiType(f)
+
+	fmt.Fprintf(b, "// Returns the API %s of the %s model.\n", f.Name, f.Name)
+	fmt.Fprintf(b, "func %s(m %s) %s {\n", g.funcName(f, "ToAPI"), model, apiType)
+	fmt.Fprintf(b, "c := %s{}\n", apiType)
+	for _, ff := range f.Fields {
+		switch {
+		case ff.Kind == kindObject || ff.Kind == kindOneOf:
+			if ff.Required {
+				fmt.Fprintf(b, "c.%s = %s(m.%s)\n", ff.GoName, g.funcName(ff, "ToAPI"), ff.GoName)
+			} else {
+				fmt.Fprintf(b, "if m.%s != nil {\nv := %s(*m.%s)\nc.%s = &v\n}\n",
+					ff.GoName, g.funcName(ff, "ToAPI"), ff.GoName, ff.GoName)
+			}
+		case ff.Secret && !ff.Required:
+			// Copied, as secret references are resolved in the body.
+			fmt.Fprintf(b, "if m.%s != nil {\nv := *m.%s\nc.%s = &v\n}\n", ff.GoName, ff.GoName, ff.GoName)
+		default:
+			fmt.Fprintf(b, "c.%s = m.%s\n", ff.GoName, ff.GoName)
+			if !ff.Required && hasDefault(ff) {
+				fmt.Fprintf(b, "if c.%s == nil {\nv := %s\nc.%s = &v\n}\n", ff.GoName, defaultLiteral(ff), ff.GoName)
+			}
+		}
+	}
+	b.WriteString("return c\n}\n\n")
+
+	fmt.Fprintf(b, "// Returns the %s model of the API %s.\n", f.Name, f.Name)
+	b.WriteString("// Secrets are left as Airbyte returns them.\n")
+	fmt.Fprintf(b, "func %s(c %s) %s {\n", g.funcName(f, "FromAPI"), apiType, model)
+	fmt.Fprintf(b, "m := %s{}\n", model)
+	for _, ff := range f.Fields {
+		switch {
+		case ff.Kind == kindObject || ff.Kind == kindOneOf:
+			if ff.Required {
+				fmt.Fprintf(b, "m.%s = %s(c.%s)\n", ff.GoName, g.funcName(ff, "FromAPI"), ff.GoName)
+			} else {
+				fmt.Fprintf(b, "if c.%s != nil {\nv := %s(*c.%s)\nm.%s = &v\n}\n",
+					ff.GoName, g.funcName(ff, "FromAPI"), ff.GoName, ff.GoName)
+			}
+		default:
+			fmt.Fprintf(b, "m.%s = c.%s\n", ff.GoName, ff.GoName)
+		}
+	}
+	b.WriteString("return m\n}\n\n")
+
+	fmt.Fprintf(b, "// Checks the %s values Airbyte would reject.\n", f.Name)
+	fmt.Fprintf(b, "func %s(m %s) error {\n", g.funcName(f, "Validate"), model)
+	v := &validations{b: b}
+	for _, ff := range f.Fields {
+		switch {
+		case ff.Kind == kindObject || ff.Kind == kindOneOf:
+			if ff.Required {
+				v.check(fmt.Sprintf("%s(m.%s)", g.funcName(ff, "Validate"), ff.GoName))
+			} else {
+				fmt.Fprintf(b, "if m.%s != nil {\n", ff.GoName)
+				v.check(fmt.Sprintf("%s(*m.%s)", g.funcName(ff, "Validate"), ff.GoName))
+				b.WriteString("}\n")
+			}
+		default:
+			v.scalar(ff, "m."+ff.GoName)
+		}
+	}
+	b.WriteString("return nil\n}\n\n")
+
+	if !hasSecrets(f) {
+		return
+	}
+
+	for _, side := range []struct{ suffix, typ, what string }{
+		{"Secrets", model, "model"},
+		{"APISecrets", apiType, "API body"},
+	} {
+		fmt.Fprintf(b, "// Returns the secrets set in the %s %s, in a stable order.\n", f.Name, side.what)
+		fmt.Fprintf(b, "func %s(c *%s) []*string {\n", g.funcName(f, side.suffix), side.typ)
+		b.WriteString("secrets := []*string{}\n")
+		for _, ff := range f.Fields {
+			if !hasSecrets(ff) {
+				continue
+			}
+			switch {
+			case ff.Kind == kindObject || ff.Kind == kindOneOf:
+				if ff.Required {
+					fmt.Fprintf(b, "secrets = append(secrets, %s(&c.%s)...)\n", g.funcName(ff, side.suffix), ff.GoName)
+				} else {
+					fmt.Fprintf(b, "if c.%s != nil {\nsecrets = append(secrets, %s(c.%s)...)\n}\n",
+						ff.GoName, g.funcName(ff, side.suffix), ff.GoName)
+				}
+			case ff.Required:
+				fmt.Fprintf(b, "secrets = append(secrets, &c.%s)\n", ff.GoName)
+			default:
+				fmt.Fprintf(b, "if c.%s != nil {\nsecrets = append(secrets, c.%s)\n}\n", ff.GoName, ff.GoName)
+			}
+		}
+		b.WriteString("return secrets\n}\n\n")
+	}
+}
+
+func (g *generator) oneOfFuncs(b *bytes.Buffer, f *field) {
+	model, apiType := g.modelType(f), "api."+g.apiType(f)
+	disc := goName(f.Discriminator)
+
+	fmt.Fprintf(b, "// Returns the API %s of the %s model, with %s set\n", f.Name, f.Name, f.Discriminator)
+	b.WriteString("// to the value of the configured block.\n")
+	fmt.Fprintf(b, "func %s(m %s) %s {\n", g.funcName(f, "ToAPI"), model, apiType)
+	fmt.Fprintf(b, "c := %s{}\n", apiType)
+	for _, v := range f.Variants {
+		fmt.Fprintf(b, "if %s {\n", v.isSet("m"))
+		fmt.Fprintf(b, "c.%s = %q\n", disc, v.Value)
+		for _, vf := range v.Fields {
+			src := "m." + v.GoName + "." + vf.GoName
+			switch {
+			case vf.Required && isScalar(vf) && unionPointer(f, vf.Name):
+				fmt.Fprintf(b, "{\nv := %s\nc.%s = &v\n}\n", src, vf.GoName)
+			case vf.Secret && !vf.Required:
+				// Copied, as secret references are resolved in the body.
+				fmt.Fprintf(b, "if %s != nil {\nv := *%s\nc.%s = &v\n}\n", src, src, vf.GoName)
+			default:
+				fmt.Fprintf(b, "c.%s = %s\n", vf.GoName, src)
+				if !vf.Required && hasDefault(vf) {
+					fmt.Fprintf(b, "if c.%s == nil {\nv := %s\nc.%s = &v\n}\n", vf.GoName, defaultLiteral(vf), vf.GoName)
+				}
+			}
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("return c\n}\n\n")
+
+	fmt.Fprintf(b, "// Returns the %s model of the API %s, with the block\n", f.Name, f.Name)
+	fmt.Fprintf(b, "// matching %s set. Secrets are left as Airbyte returns them.\n", f.Discriminator)
+	fmt.Fprintf(b, "func %s(c %s) %s {\n", g.funcName(f, "FromAPI"), apiType, model)
+	fmt.Fprintf(b, "m := %s{}\n", model)
+	fmt.Fprintf(b, "switch c.%s {\n", disc)
+	for _, v := range f.Variants {
+		vModel := g.variantType(f, v)
+		fmt.Fprintf(b, "case %q:\n", v.Value)
+		if v.isFlag() {
+			fmt.Fprintf(b, "v := true\nm.%s = &v\n", v.GoName)
+			continue
+		}
+		fmt.Fprintf(b, "v := %s{}\n", vModel)
+		for _, vf := range v.Fields {
+			switch {
+			case vf.Required && isScalar(vf) && unionPointer(f, vf.Name):
+				fmt.Fprintf(b, "if c.%s != nil {\nv.%s = *c.%s\n}\n", vf.GoName, vf.GoName, vf.GoName)
+			default:
+				fmt.Fprintf(b, "v.%s = c.%s\n", vf.GoName, vf.GoName)
+			}
+		}
+		fmt.Fprintf(b, "m.%s = &v\n", v.GoName)
+	}
+	b.WriteString("}\nreturn m\n}\n\n")
+
+	fmt.Fprintf(b, "// Checks that exactly one %s block is set, and its values.\n", f.Name)
+	fmt.Fprintf(b, "func %s(m %s) error {\n", g.funcName(f, "Validate"), model)
+	set := []string{}
+	for _, v := range f.Variants {
+		set = append(set, v.isSet("m"))
+	}
+	vs := &validations{b: b}
+	vs.check(fmt.Sprintf("%s.validate(%s)", g.funcName(f, ""), strings.Join(set, ", ")))
+	for _, v := range f.Variants {
+		checked := false
+		for _, vf := range v.Fields {
+			if !vf.Secret && (len(vf.Enum) > 0 || vf.Pattern != "") {
+				checked = true
+			}
+		}
+		if !checked {
+			continue
+		}
+		fmt.Fprintf(b, "if m.%s != nil {\n", v.GoName)
+		for _, vf := range v.Fields {
+			vs.scalar(vf, "m."+v.GoName+"."+vf.GoName)
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("return nil\n}\n\n")
+
+	if !hasSecrets(f) {
+		return
+	}
+
+	fmt.Fprintf(b, "// Returns the secrets set in the %s model, in a stable order.\n", f.Name)
+	fmt.Fprintf(b, "func %s(c *%s) []*string {\n", g.funcName(f, "Secrets"), model)
+	b.WriteString("secrets := []*string{}\n")
+	for _, v := range f.Variants {
+		if !hasSecrets(&field{Fields: v.Fields}) {
+			continue
+		}
+		fmt.Fprintf(b, "if c.%s != nil {\n", v.GoName)
+		for _, vf := range v.Fields {
+			if !vf.Secret {
+				continue
+			}
+			if vf.Required {
+				fmt.Fprintf(b, "secrets = append(secrets, &c.%s.%s)\n", v.GoName, vf.GoName)
+			} else {
+				fmt.Fprintf(b, "if c.%s.%s != nil {\nsecrets = append(secrets, c.%s.%s)\n}\n",
+					v.GoName, vf.GoName, v.GoName, vf.GoName)
+			}
+		}
+		b.WriteString("}\n")
+	}
+	b.WriteString("return secrets\n}\n\n")
+
+	fmt.Fprintf(b, "// Returns the secrets set in the %s API body, in a stable order.\n", f.Name)
+	fmt.Fprintf(b, "func %s(c *%s) []*string {\n", g.funcName(f, "APISecrets"), apiType)
+	b.WriteString("secrets := []*string{}\n")
+	fmt.Fprintf(b, "switch c.%s {\n", disc)
+	for _, v := range f.Variants {
+		if !hasSecrets(&field{Fields: v.Fields}) {
+			continue
+		}
+		fmt.Fprintf(b, "case %q:\n", v.Value)
+		for _, vf := range v.Fields {
+			if !vf.Secret {
+				continue
+			}
+			if unionPointer(f, vf.Name) {
+				fmt.Fprintf(b, "if c.%s != nil {\nsecrets = append(secrets, c.%s)\n}\n", vf.GoName, vf.GoName)
+			} else {
+				fmt.Fprintf(b, "secrets = append(secrets, &c.%s)\n", vf.GoName)
+			}
+		}
+	}
+	b.WriteString("}\nreturn secrets\n}\n\n")
+}
+
+// Writes the checks of a Validate function.
+type validations struct {
+	b *bytes.Buffer
+}
+
+func (v *validations) check(call string) {
+	fmt.Fprintf(v.b, "if err := %s; err != nil {\nreturn err\n}\n", call)
+}
+
+// Writes the enum and pattern checks of a string field.
+func (v *validations) scalar(f *field, expr string) {
+	if f.Kind != kindString || f.Secret {
+		return
+	}
+	value := expr
+	if !f.Required {
+		if len(f.Enum) == 0 && f.Pattern == "" {
+			return
+		}
+		fmt.Fprintf(v.b, "if %s != nil {\n", expr)
+		value = "*" + expr
+	}
+	if len(f.Enum) > 0 {
+		allowed := []string{}
+		for _, e := range f.Enum {
+			allowed = append(allowed, strconv.Quote(e))
+		}
+		v.check(fmt.Sprintf("validateOneOf(%q, %s, %s)", f.Path, value, strings.Join(allowed, ", ")))
+	}
+	if f.Pattern != "" {
+		v.check(fmt.Sprintf("validatePattern(%q, %s, %s)", f.Path, value, backquote(f.Pattern)))
+	}
+	if !f.Required {
+		v.b.WriteString("}\n")
+	}
+}
+
+// Returns a raw string literal when possible, which keeps patterns readable.
+func backquote(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// Formats generated code, reporting the code on errors to debug them.
+func formatSource(name string, b []byte) ([]byte, error) {
+	src, err := format.Source(b)
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code for %s: %s\n%s", name, err.Error(), b)
+	}
+	return src, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// Checks the code generated from testdata/source_example.json, which has
+// required, optional, enum, pattern, default, list, hidden and secret
+// properties, and a oneOf with a const and a single value enum
+// discriminator, against the golden files next to it. Run with -update
+// to rewrite them after changing the templates.
+func TestGenerateGolden(t *testing.T) {
+	dir := t.TempDir()
+	g := &generator{
+		Kind:     "source",
+		Name:     "example",
+		Title:    "Example",
+		SpecPath: filepath.Join("testdata", "source_example.json"),
+	}
+	err := run(g, dir, dir)
+	if err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	for _, name := range []string{"source_example.go", "source_example_resource.go"} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden := filepath.Join("testdata", name+".golden")
+		if *update {
+			err = os.WriteFile(golden, got, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s differs from %s, update it with go test ./cmd/connectorgen -update if the change is intended", name, golden)
+		}
+	}
+}
+
+func TestConfigFieldErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "no properties",
+			spec: `{"type": "object"}`,
+			want: "not an object with properties",
+		},
+		{
+			name: "secret int",
+			spec: `{"type": "object", "properties": {"pin": {"type": "integer", "airbyte_secret": true}}}`,
+			want: "only string secrets are supported",
+		},
+		{
+			name: "array of objects",
+			spec: `{"type": "object", "properties": {"a": {"type": "array", "items": {"type": "object"}}}}`,
+			want: "arrays of object are not supported",
+		},
+		{
+			name: "oneOf without discriminator",
+			spec: `{"type": "object", "properties": {"auth": {"type": "object", "oneOf": [
+				{"properties": {"token": {"type": "string"}}},
+				{"properties": {"key": {"type": "string"}}}
+			]}}}`,
+			want: "without a constant discriminator",
+		},
+		{
+			name: "oneOf with nested object",
+			spec: `{"type": "object", "properties": {"auth": {"type": "object", "oneOf": [
+				{"properties": {"type": {"const": "a"}, "o": {"type": "object", "properties": {"x": {"type": "string"}}}}},
+				{"properties": {"type": {"const": "b"}}}
+			]}}}`,
+			want: "nested objects in oneOf variants are not supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "spec.json")
+			err := os.WriteFile(path, []byte(tt.spec), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			spec, err := readSpec(path)
+			if err != nil {
+				t.Fatalf("readSpec() error = %v", err)
+			}
+			_, err = configField(spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("configField() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Command connectorgen generates the API and plugin code of a source or
+// destination resource from the connectionSpecification JSON Schema of
+// an Airbyte connector spec, e.g. as returned by
+// /api/v1/source_definition_specifications/get.
+//
+// It writes api/<kind>_<name>.go with the request types and client
+// methods, and plugin/<kind>_<name>_resource.go with the resource model,
+// its schema and the mapping between both. The resource still needs to
+// be registered in main.go.
+//
+// Spec properties map to attributes of the same name. Properties marked
+// airbyte_secret are sensitive and accept secret references, hidden ones
+// are left out, and a oneOf becomes a block per variant, of which
+// exactly one must be set, named after the value of its discriminator,
+// like auth_type. Objects and arrays of objects inside oneOf variants
+// aren't supported.
+//
+// Usage, from the repository root:
+//
+//	go run ./cmd/connectorgen -kind source -name mailchimp -title Mailchimp \
+//		-spec specs/source_mailchimp.json
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	g := &generator{}
+	flag.StringVar(&g.Kind, "kind", "source", "connector kind, source or destination")
+	flag.StringVar(&g.Name, "name", "", "connector name in snake case, e.g. zendesk_support")
+	flag.StringVar(&g.Title, "title", "", "connector title used in descriptions, defaults to the name")
+	flag.StringVar(&g.SpecPath, "spec", "", "path of the connector spec JSON file")
+	apiDir := flag.String("api", "api", "directory of the api package")
+	pluginDir := flag.String("plugin", "plugin", "directory of the plugin package")
+	flag.Parse()
+
+	err := run(g, *apiDir, *pluginDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connectorgen: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(g *generator, apiDir string, pluginDir string) error {
+	if g.Kind != "source" && g.Kind != "destination" {
+		return fmt.Errorf("kind must be source or destination, got %q", g.Kind)
+	}
+	if g.Name == "" || snakeName(g.Name) != g.Name {
+		return fmt.Errorf("name must be set in snake case, got %q", g.Name)
+	}
+	if g.SpecPath == "" {
+		return fmt.Errorf("spec must be set")
+	}
+	if g.Title == "" {
+		g.Title = g.Name
+	}
+
+	spec, err := readSpec(g.SpecPath)
+	if err != nil {
+		return err
+	}
+	g.Config, err = configField(spec)
+	if err != nil {
+		return err
+	}
+	g.SpecPath = filepath.ToSlash(g.SpecPath)
+
+	apiPath := filepath.Join(apiDir, g.Kind+"_"+g.Name+".go")
+	pluginPath := filepath.Join(pluginDir, g.Kind+"_"+g.Name+"_resource.go")
+
+	b := &bytes.Buffer{}
+	err = apiTemplate.Execute(b, g)
+	if err != nil {
+		return err
+	}
+	err = writeSource(apiPath, b.Bytes())
+	if err != nil {
+		return err
+	}
+
+	b.Reset()
+	err = resourceTemplate.Execute(b, g)
+	if err != nil {
+		return err
+	}
+	return writeSource(pluginPath, b.Bytes())
+}
+
+func writeSource(path string, b []byte) error {
+	src, err := formatSource(path, b)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, src, 0644)
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of connector configuration values.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindList
+	kindObject
+	kindOneOf
+)
+
+// JSON Schema of a connector configuration value, as found in the
+// connectionSpecification of a connector spec.
+type jsonSchema struct {
+	Type          interface{}            `json:"type"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	Required      []string               `json:"required"`
+	Properties    map[string]*jsonSchema `json:"properties"`
+	OneOf         []*jsonSchema          `json:"oneOf"`
+	Items         *jsonSchema            `json:"items"`
+	Enum          []interface{}          `json:"enum"`
+	Const         interface{}            `json:"const"`
+	Default       interface{}            `json:"default"`
+	Pattern       string                 `json:"pattern"`
+	Order         *int                   `json:"order"`
+	AirbyteSecret bool                   `json:"airbyte_secret"`
+	AirbyteHidden bool                   `json:"airbyte_hidden"`
+}
+
+// Configuration value of a connector, with the Go names it maps to.
+type field struct {
+	// Property name in the spec, used for both the JSON and pctsdk tags.
+	Name string
+	// Go name of the struct fields.
+	GoName string
+	// Attribute path, e.g. connection_configuration.credentials, for errors.
+	Path string
+
+	Kind        kind
+	Elem        kind
+	Required    bool
+	Secret      bool
+	Description string
+	Enum        []string
+	Pattern     string
+	Default     interface{}
+
+	// Object fields, or the common fields of oneOf variants.
+	Fields []*field
+	// Go type name of objects and oneOf, without prefix or suffix.
+	TypeName string
+
+	// Variants of oneOf, told apart by the Discriminator property.
+	Variants      []*variant
+	Discriminator string
+}
+
+// Variant of a oneOf, declared as a nested block named Key.
+type variant struct {
+	Key    string
+	GoName string
+	Value  string
+	Fields []*field
+}
+
+// Reads the connection specification from a file holding either a
+// connector spec or the specification itself.
+func readSpec(path string) (*jsonSchema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec struct {
+		ConnectionSpecification *jsonSchema `json:"connectionSpecification"`
+	}
+	err = json.Unmarshal(b, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse spec %s: %s", path, err.Error())
+	}
+	if spec.ConnectionSpecification != nil {
+		return spec.ConnectionSpecification, nil
+	}
+
+	s := jsonSchema{}
+	err = json.Unmarshal(b, &s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse spec %s: %s", path, err.Error())
+	}
+	return &s, nil
+}
+
+// Returns the connection configuration field of a specification.
+func configField(s *jsonSchema) (*field, error) {
+	if s.typeName() != "object" || len(s.Properties) == 0 {
+		return nil, fmt.Errorf("connection specification is not an object with properties")
+	}
+
+	f := &field{
+		Name:     "connection_configuration",
+		GoName:   "ConnectionConfiguration",
+		Path:     "connection_configuration",
+		Kind:     kindObject,
+		Required: true,
+		TypeName: "ConnConfig",
+	}
+	fields, err := objectFields(s, f.Path, "", "")
+	if err != nil {
+		return nil, err
+	}
+	f.Fields = fields
+	return f, nil
+}
+
+// Returns the fields of an object schema in spec order. Hidden
+// properties and the skipped one, like a oneOf discriminator, are left out.
+func objectFields(s *jsonSchema, path string, typePrefix string, skip string) ([]*field, error) {
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	fields := []*field{}
+	for _, name := range orderedProperties(s.Properties) {
+		p := s.Properties[name]
+		if p.AirbyteHidden || name == skip {
+			continue
+		}
+
+		f, err := newField(name, p, path+"."+name, typePrefix)
+		if err != nil {
+			return nil, err
+		}
+		f.Required = required[name]
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func newField(name string, s *jsonSchema, path string, typePrefix string) (*field, error) {
+	f := &field{
+		Name:        name,
+		GoName:      goName(name),
+		Path:        path,
+		Secret:      s.AirbyteSecret,
+		Description: description(s),
+		Default:     s.Default,
+	}
+
+	switch s.typeName() {
+	case "string":
+		f.Kind = kindString
+		for _, v := range s.Enum {
+			f.Enum = append(f.Enum, fmt.Sprint(v))
+		}
+		if s.Pattern != "" {
+			// Patterns RE2 can't compile, e.g. with lookaheads, are left
+			// to Airbyte to check.
+			if _, err := regexp.Compile(s.Pattern); err == nil {
+				f.Pattern = s.Pattern
+			} else {
+				warnf("%s: pattern not checked: %s", path, err.Error())
+			}
+		}
+	case "integer":
+		f.Kind = kindInt
+	case "number":
+		f.Kind = kindFloat
+	case "boolean":
+		f.Kind = kindBool
+	case "array":
+		if s.Items == nil {
+			return nil, fmt.Errorf("%s: array without items is not supported", path)
+		}
+		switch s.Items.typeName() {
+		case "string":
+			f.Elem = kindString
+		case "integer":
+			f.Elem = kindInt
+		case "number":
+			f.Elem = kindFloat
+		case "boolean":
+			f.Elem = kindBool
+		default:
+			return nil, fmt.Errorf("%s: arrays of %s are not supported", path, s.Items.typeName())
+		}
+		f.Kind = kindList
+	case "object":
+		f.TypeName = typePrefix + f.GoName
+		if len(s.OneOf) > 0 {
+			f.Kind = kindOneOf
+			err := oneOfVariants(f, s)
+			if err != nil {
+				return nil, err
+			}
+			return f, nil
+		}
+		if len(s.Properties) == 0 {
+			return nil, fmt.Errorf("%s: objects without properties are not supported", path)
+		}
+		f.Kind = kindObject
+		fields, err := objectFields(s, path, f.TypeName, "")
+		if err != nil {
+			return nil, err
+		}
+		f.Fields = fields
+	default:
+		return nil, fmt.Errorf("%s: type %q is not supported", path, s.typeName())
+	}
+
+	if f.Secret && f.Kind != kindString {
+		return nil, fmt.Errorf("%s: only string secrets are supported", path)
+	}
+	return f, nil
+}
+
+// Sets the variants of a oneOf field. Each variant must set a constant
+// discriminator property, like auth_type, which tells them apart.
+func oneOfVariants(f *field, s *jsonSchema) error {
+	discriminator := ""
+	for name, p := range s.OneOf[0].Properties {
+		if p.constValue() == "" {
+			continue
+		}
+		shared := true
+		for _, v := range s.OneOf[1:] {
+			if v.Properties[name] == nil || v.Properties[name].constValue() == "" {
+				shared = false
+				break
+			}
+		}
+		if shared && (discriminator == "" || name < discriminator) {
+			discriminator = name
+		}
+	}
+	if discriminator == "" {
+		return fmt.Errorf("%s: oneOf without a constant discriminator property is not supported", f.Path)
+	}
+	f.Discriminator = discriminator
+
+	keys := map[string]bool{}
+	for _, v := range s.OneOf {
+		value := v.Properties[discriminator].constValue()
+		key := snakeName(value)
+		if key == "" {
+			key = snakeName(v.Title)
+		}
+		if keys[key] {
+			return fmt.Errorf("%s: several oneOf variants are named %s", f.Path, key)
+		}
+		keys[key] = true
+
+		vr := &variant{
+			Key:    key,
+			GoName: goName(key),
+			Value:  value,
+		}
+		fields, err := objectFields(v, f.Path+"."+key, f.TypeName+vr.GoName, discriminator)
+		if err != nil {
+			return err
+		}
+		for _, vf := range fields {
+			if vf.Kind == kindObject || vf.Kind == kindOneOf {
+				return fmt.Errorf("%s: nested objects in oneOf variants are not supported", vf.Path)
+			}
+			vr.Fields = append(vr.Fields, vf)
+		}
+		f.Variants = append(f.Variants, vr)
+	}
+
+	// The API type holds the fields of all the variants.
+	seen := map[string]*field{}
+	for _, v := range f.Variants {
+		for _, vf := range v.Fields {
+			if other, ok := seen[vf.Name]; ok {
+				if other.Kind != vf.Kind || other.Elem != vf.Elem {
+					return fmt.Errorf("%s: %s has different types in oneOf variants", f.Path, vf.Name)
+				}
+				continue
+			}
+			seen[vf.Name] = vf
+			f.Fields = append(f.Fields, vf)
+		}
+	}
+	return nil
+}
+
+// Returns the property names ordered by their order keyword, then name.
+func orderedProperties(properties map[string]*jsonSchema) []string {
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		oi, oj := properties[names[i]].Order, properties[names[j]].Order
+		switch {
+		case oi != nil && oj != nil && *oi != *oj:
+			return *oi < *oj
+		case oi != nil && oj == nil:
+			return true
+		case oi == nil && oj != nil:
+			return false
+		default:
+			return names[i] < names[j]
+		}
+	})
+	return names
+}
+
+// Returns the JSON type, ignoring null in type lists like ["string", "null"].
+func (s *jsonSchema) typeName() string {
+	switch t := s.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				return name
+			}
+		}
+	case nil:
+		if len(s.OneOf) > 0 || len(s.Properties) > 0 {
+			return "object"
+		}
+	}
+	return ""
+}
+
+// Returns the constant value of a property, from either const or a
+// single value enum.
+func (s *jsonSchema) constValue() string {
+	if s.Const != nil {
+		return fmt.Sprint(s.Const)
+	}
+	if len(s.Enum) == 1 {
+		return fmt.Sprint(s.Enum[0])
+	}
+	return ""
+}
+
+var (
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Returns the description, or the title when there is none, as plain
+// text without a trailing period.
+func description(s *jsonSchema) string {
+	d := s.Description
+	if d == "" {
+		d = s.Title
+	}
+	d = htmlTagPattern.ReplaceAllString(d, "")
+	d = whitespacePattern.ReplaceAllString(d, " ")
+	return strings.TrimSuffix(strings.TrimSpace(d), ".")
+}
+
+// Returns a snake case name for a value like "OAuth2.0" or "API Key".
+func snakeName(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			underscore = false
+			b.WriteRune(r)
+		} else {
+			underscore = true
+		}
+	}
+	return b.String()
+}
+
+// Returns the Go name for a snake case name, e.g. ClientId for client_id.
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snakeName(name), "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	s := b.String()
+	if s == "" || (s[0] >= '0' && s[0] <= '9') {
+		s = "X" + s
+	}
+	return s
+}
+
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "connectorgen: "+format+"\n", args...)
+}
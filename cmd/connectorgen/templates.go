@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bytes"
+	"text/template"
+)
+
+var funcs = template.FuncMap{
+	"apiStructs": func(g *generator) string {
+		b := &bytes.Buffer{}
+		g.apiStructs(b)
+		return b.String()
+	},
+	"modelStructs": func(g *generator) string {
+		b := &bytes.Buffer{}
+		g.modelStructs(b)
+		return b.String()
+	},
+	"attributes": func(g *generator) string {
+		b := &bytes.Buffer{}
+		for _, f := range g.Config.Fields {
+			g.attribute(b, f)
+		}
+		return b.String()
+	},
+	"mappingFuncs": func(g *generator) string {
+		b := &bytes.Buffer{}
+		g.mappingFuncs(b)
+		return b.String()
+	},
+}
+
+var apiTemplate = template.Must(template.New("api").Funcs(funcs).Parse(
+	`// Code generated by connectorgen from {{.SpecPath}}. DO NOT EDIT.
+
+package api
+
+import "encoding/json"
+
+type {{.APIType}}ID struct {
+	{{.IdField}} string ` + "`json:\"{{.Kind}}Id\"`" + `
+}
+
+type {{.APIType}} struct {
+	Name string ` + "`json:\"name\"`" + `
+	{{.IdField}} string ` + "`json:\"{{.Kind}}Id,omitempty\"`" + `
+	{{.DefinitionIdField}} string ` + "`json:\"{{.Kind}}DefinitionId,omitempty\"`" + `
+	WorkspaceId string ` + "`json:\"workspaceId,omitempty\"`" + `
+	ConnectionConfiguration {{.APIType}}ConnConfig ` + "`json:\"connectionConfiguration\"`" + `
+}
+
+{{apiStructs .}}
+
+func (c *Client) Create{{.GoName}}{{.KindName}}(payload {{.APIType}}) ({{.APIType}}, error) {
+	operation := "{{.Kind}}s/create"
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return {{.APIType}}{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return {{.APIType}}{}, err
+	}
+	{{.Kind}} := {{.APIType}}{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &{{.Kind}})
+		return {{.Kind}}, err
+	} else {
+		return {{.Kind}}, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) Read{{.GoName}}{{.KindName}}({{.Kind}}Id string) ({{.APIType}}, error) {
+	operation := "{{.Kind}}s/get"
+	id := {{.APIType}}ID{ {{- .Kind}}Id}
+	body, err := json.Marshal(id)
+	if err != nil {
+		return {{.APIType}}{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return {{.APIType}}{}, err
+	}
+
+	{{.Kind}} := {{.APIType}}{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &{{.Kind}})
+		return {{.Kind}}, err
+	} else {
+		return {{.Kind}}, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) Update{{.GoName}}{{.KindName}}(payload {{.APIType}}) ({{.APIType}}, error) {
+	operation := "{{.Kind}}s/update"
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return {{.APIType}}{}, err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return {{.APIType}}{}, err
+	}
+
+	{{.Kind}} := {{.APIType}}{}
+	if statusCode >= 200 && statusCode <= 299 {
+		err = json.Unmarshal(b, &{{.Kind}})
+		return {{.Kind}}, err
+	} else {
+		return {{.Kind}}, c.getAPIError(statusCode, b)
+	}
+}
+
+func (c *Client) Delete{{.GoName}}{{.KindName}}({{.Kind}}Id string) error {
+	operation := "{{.Kind}}s/delete"
+	id := {{.APIType}}ID{ {{- .Kind}}Id}
+	body, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+
+	b, statusCode, err := c.call(operation, body)
+	if err != nil {
+		return err
+	}
+
+	if statusCode >= 200 && statusCode <= 299 {
+		return nil
+	} else {
+		return c.getAPIError(statusCode, b)
+	}
+}
+`))
+
+var resourceTemplate = template.Must(template.New("resource").Funcs(funcs).Parse(
+	`// Code generated by connectorgen from {{.SpecPath}}. DO NOT EDIT.
+
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Resource implementation.
+type {{.Prefix}}Resource struct {
+	Client *api.Client
+}
+
+type {{.Prefix}}ResourceModel struct {
+	Name string ` + "`pctsdk:\"name\"`" + `
+	{{.IdField}} string ` + "`pctsdk:\"{{.IdAttribute}}\"`" + `
+	{{.DefinitionIdField}} string ` + "`pctsdk:\"{{.DefinitionIdAttribute}}\"`" + `
+	WorkspaceId string ` + "`pctsdk:\"workspace_id\"`" + `
+	ConnectionConfiguration {{.Prefix}}ConnConfigModel ` + "`pctsdk:\"connection_configuration\"`" + `
+	AdoptExisting *bool ` + "`pctsdk:\"adopt_existing\"`" + `
+{{- if .HasSecrets}}
+	SecretsHash *string ` + "`pctsdk:\"secrets_hash\"`" + `
+{{- end}}
+}
+
+{{modelStructs .}}
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ schema.ResourceService = &{{.Prefix}}Resource{}
+)
+
+// Helper function to return a resource service instance.
+func New{{.APIType}}Resource() schema.ResourceService {
+	return &{{.Prefix}}Resource{}
+}
+
+// Metadata returns the resource type name.
+// It is always provider name + "_" + resource type name.
+func (r *{{.Prefix}}Resource) Metadata(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{
+		TypeName: req.TypeName + "_{{.TypeName}}",
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *{{.Prefix}}Resource) Configure(req *schema.ServiceRequest) *schema.ServiceResponse {
+	if req.ResourceData == "" {
+		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
+	}
+
+	client, err := configuredClient(req.ResourceData)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	r.Client = client
+
+	return &schema.ServiceResponse{}
+}
+
+// Schema defines the schema for the resource.
+func (r *{{.Prefix}}Resource) Schema() *schema.ServiceResponse {
+	s := &schema.Schema{
+		Description: "{{.KindName}} {{.Title}} resource for Airbyte",
+		Attributes: map[string]schema.Attribute{
+			"name": &schema.StringAttribute{
+				Description: "Name",
+				Required:    true,
+			},
+			"{{.IdAttribute}}": &schema.StringAttribute{
+				Description: "{{.KindName}} ID",
+				Computed:    true,
+			},
+			"{{.DefinitionIdAttribute}}": &schema.StringAttribute{
+				Description: "Definition ID. Changing it replaces the {{.Kind}}, which is refused while connections use it",
+				Required:    true,
+			},
+			"workspace_id": &schema.StringAttribute{
+				Description: "Workspace ID. Changing it replaces the {{.Kind}}, which is refused while connections use it",
+				Required:    true,
+			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing {{.Kind}} with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several {{.Kind}}s match. Defaults to false.",
+				Optional: true,
+			},
+{{- if .HasSecrets}}
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
+{{- end}}
+			"connection_configuration": &schema.MapAttribute{
+				Description: "Connection configuration",
+				Required:    true,
+				Attributes: map[string]schema.Attribute{
+					{{attributes .}}
+				},
+			},
+		},
+	}
+
+	sEnc, err := fwhelpers.Encode(s)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		SchemaContents: sEnc,
+	}
+}
+
+// Create a new resource
+func (r *{{.Prefix}}Resource) Create(req *schema.ServiceRequest) *schema.ServiceResponse {
+	// Retrieve values from plan
+	var plan {{.Prefix}}ResourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = {{.Prefix}}ConnConfigValidate(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Generate API request body from plan
+	body := api.{{.APIType}}{}
+	body.Name = plan.Name
+	body.{{.DefinitionIdField}} = plan.{{.DefinitionIdField}}
+	body.WorkspaceId = plan.WorkspaceId
+	body.ConnectionConfiguration = {{.Prefix}}ConnConfigToAPI(plan.ConnectionConfiguration)
+{{- if .HasSecrets}}
+	// Resolve secret references right before the API call.
+	secrets := {{.Prefix}}ConnConfigAPISecrets(&body.ConnectionConfiguration)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+{{- end}}
+
+	// Adopt a matching existing {{.Kind}}, e.g. one left over by a timed out
+	// create, or create a new one.
+	{{.Kind}}Id := ""
+	if boolValue(plan.AdoptExisting) {
+		{{.Kind}}Id, err = adoptable{{.IdField}}(r.Client, plan.WorkspaceId, plan.Name, plan.{{.DefinitionIdField}})
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var {{.Kind}} api.{{.APIType}}
+	if {{.Kind}}Id != "" {
+		body.{{.IdField}} = {{.Kind}}Id
+		body.{{.DefinitionIdField}} = ""
+		body.WorkspaceId = ""
+		{{.Kind}}, err = r.Client.Update{{.GoName}}{{.KindName}}(body)
+	} else {
+		{{.Kind}}, err = r.Client.Create{{.GoName}}{{.KindName}}(body)
+	}
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Update resource state with response body
+	state := {{.Prefix}}ResourceModel{}
+	state.Name = {{.Kind}}.Name
+	state.{{.DefinitionIdField}} = {{.Kind}}.{{.DefinitionIdField}}
+	state.{{.IdField}} = {{.Kind}}.{{.IdField}}
+	state.WorkspaceId = {{.Kind}}.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+{{- if .HasSecrets}}
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
+{{- end}}
+	state.ConnectionConfiguration = {{.Prefix}}ConnConfigFromAPI({{.Kind}}.ConnectionConfiguration)
+{{- if .HasSecrets}}
+	keepSecrets(nil,
+		{{.Prefix}}ConnConfigSecrets(&state.ConnectionConfiguration),
+		{{.Prefix}}ConnConfigSecrets(&plan.ConnectionConfiguration),
+	)
+{{- end}}
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.{{.IdField}},
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Read resource information
+func (r *{{.Prefix}}Resource) Read(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var state {{.Prefix}}ResourceModel
+
+	// Get current state
+	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+{{- if .HasSecrets}}
+
+	prior := state
+{{- end}}
+
+	res := schema.ServiceResponse{}
+
+	if req.StateID != "" {
+		// Query using existing previous state.
+		{{.Kind}}, err := r.Client.Read{{.GoName}}{{.KindName}}(req.StateID)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+
+		// Update state with refreshed value
+		state.Name = {{.Kind}}.Name
+		state.{{.DefinitionIdField}} = {{.Kind}}.{{.DefinitionIdField}}
+		state.{{.IdField}} = {{.Kind}}.{{.IdField}}
+		state.WorkspaceId = {{.Kind}}.WorkspaceId
+		state.ConnectionConfiguration = {{.Prefix}}ConnConfigFromAPI({{.Kind}}.ConnectionConfiguration)
+{{- if .HasSecrets}}
+		keepSecrets(state.SecretsHash,
+			{{.Prefix}}ConnConfigSecrets(&state.ConnectionConfiguration),
+			{{.Prefix}}ConnConfigSecrets(&prior.ConnectionConfiguration),
+		)
+{{- end}}
+
+		res.StateID = state.{{.IdField}}
+	} else {
+		// No previous state exists.
+		res.StateID = ""
+	}
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	res.StateContents = stateEnc
+
+	return &res
+}
+
+func (r *{{.Prefix}}Resource) Update(req *schema.ServiceRequest) *schema.ServiceResponse {
+	// Retrieve values from plan
+	var plan {{.Prefix}}ResourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = {{.Prefix}}ConnConfigValidate(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Airbyte can't move a {{.Kind}} to another workspace or definition,
+	// so changing those replaces the {{.Kind}}.
+	current, err := r.Client.Read{{.KindName}}(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.{{.DefinitionIdField}} != plan.{{.DefinitionIdField}} {
+		return replaceActor(r, req, r.Client, "{{.Kind}}", current.WorkspaceId)
+	}
+
+	// Generate API request body from plan
+	body := api.{{.APIType}}{}
+	body.Name = plan.Name
+	body.{{.IdField}} = plan.{{.IdField}}
+	body.ConnectionConfiguration = {{.Prefix}}ConnConfigToAPI(plan.ConnectionConfiguration)
+{{- if .HasSecrets}}
+	// Resolve secret references right before the API call.
+	secrets := {{.Prefix}}ConnConfigAPISecrets(&body.ConnectionConfiguration)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+{{- end}}
+
+	// Update existing {{.Kind}}
+	_, err = r.Client.Update{{.GoName}}{{.KindName}}(body)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Fetch updated items
+	{{.Kind}}, err := r.Client.Read{{.GoName}}{{.KindName}}(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Update state with refreshed value
+	state := {{.Prefix}}ResourceModel{}
+	state.Name = {{.Kind}}.Name
+	state.{{.DefinitionIdField}} = {{.Kind}}.{{.DefinitionIdField}}
+	state.{{.IdField}} = {{.Kind}}.{{.IdField}}
+	state.WorkspaceId = {{.Kind}}.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+{{- if .HasSecrets}}
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
+{{- end}}
+	state.ConnectionConfiguration = {{.Prefix}}ConnConfigFromAPI({{.Kind}}.ConnectionConfiguration)
+{{- if .HasSecrets}}
+	keepSecrets(nil,
+		{{.Prefix}}ConnConfigSecrets(&state.ConnectionConfiguration),
+		{{.Prefix}}ConnConfigSecrets(&plan.ConnectionConfiguration),
+	)
+{{- end}}
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.{{.IdField}},
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Delete deletes the resource and removes the state on success.
+func (r *{{.Prefix}}Resource) Delete(req *schema.ServiceRequest) *schema.ServiceResponse {
+	// Delete existing {{.Kind}}
+	err := r.Client.Delete{{.GoName}}{{.KindName}}(req.StateID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{}
+}
+
+{{mappingFuncs .}}
+`))
@@ -13,6 +13,9 @@ var version string
 func main() {
 	server.Serve(version, plugin.NewProvider, []func() schema.ResourceService{
 		plugin.NewConnectionResource,
+		plugin.NewConnectionStreamStateResource,
+		plugin.NewConnectionStateDataSource,
+		plugin.NewSourceSchemaDataSource,
 
 		// plugin.NewSourceFakerResource,
 		plugin.NewSourcePipedriveResource,
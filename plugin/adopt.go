@@ -0,0 +1,83 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Returns the ID of the source in the workspace with the name and
+// definition, or "" when there is none.
+func adoptableSourceId(client *api.Client, workspaceId string, name string, definitionId string) (string, error) {
+	sources, err := client.ListSources(workspaceId)
+	if err != nil {
+		return "", err
+	}
+
+	ids := []string{}
+	for _, s := range sources {
+		if s.Name == name && s.SourceDefinitionId == definitionId {
+			ids = append(ids, s.SourceId)
+		}
+	}
+
+	return adoptableId("source", name, ids)
+}
+
+// Returns the ID of the destination in the workspace with the name and
+// definition, or "" when there is none.
+func adoptableDestinationId(client *api.Client, workspaceId string, name string, definitionId string) (string, error) {
+	destinations, err := client.ListDestinations(workspaceId)
+	if err != nil {
+		return "", err
+	}
+
+	ids := []string{}
+	for _, d := range destinations {
+		if d.Name == name && d.DestinationDefinitionId == definitionId {
+			ids = append(ids, d.DestinationId)
+		}
+	}
+
+	return adoptableId("destination", name, ids)
+}
+
+// Returns the ID of the connection with the name between the source and
+// destination, or "" when there is none.
+func adoptableConnectionId(client *api.Client, name string, sourceId string, destinationId string) (string, error) {
+	// Connections are listed per workspace, which is the one of the source.
+	source, err := client.ReadSource(sourceId)
+	if err != nil {
+		return "", err
+	}
+
+	connections, err := client.ListWebBackendConnections(source.WorkspaceId)
+	if err != nil {
+		return "", err
+	}
+
+	ids := []string{}
+	for _, c := range connections {
+		if c.Name == name && c.Source.SourceId == sourceId && c.Destination.DestinationId == destinationId {
+			ids = append(ids, c.ConnectionId)
+		}
+	}
+
+	return adoptableId("connection", name, ids)
+}
+
+func adoptableId(kind string, name string, ids []string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", nil
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf(
+			"unable to adopt an existing %s: %d %ss named %q match (%s).\n"+
+				"Delete the duplicates or rename the %s first.",
+			kind, len(ids), kind, name, strings.Join(ids, ", "), kind,
+		)
+	}
+}
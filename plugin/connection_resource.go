@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/zipstack/pct-plugin-framework/fwhelpers"
@@ -15,13 +16,22 @@ type connectionResource struct {
 }
 
 type connectionResourceModel struct {
-	Name          string           `pctsdk:"name"`
-	SourceID      string           `pctsdk:"source_id"`
-	DestinationID string           `pctsdk:"destination_id"`
-	ConnectionID  string           `pctsdk:"connection_id"`
-	Status        string           `pctsdk:"status"`
-	ScheduleType  string           `pctsdk:"schedule_type"`
-	ScheduleData  connScheduleData `pctsdk:"schedule_data"`
+	Name                         string                       `pctsdk:"name"`
+	SourceID                     string                       `pctsdk:"source_id"`
+	DestinationID                string                       `pctsdk:"destination_id"`
+	ConnectionID                 string                       `pctsdk:"connection_id"`
+	Status                       string                       `pctsdk:"status"`
+	ScheduleType                 string                       `pctsdk:"schedule_type"`
+	ScheduleData                 connScheduleData             `pctsdk:"schedule_data"`
+	NamespaceDefinition          *string                      `pctsdk:"namespace_definition"`
+	NamespaceFormat              *string                      `pctsdk:"namespace_format"`
+	Prefix                       *string                      `pctsdk:"prefix"`
+	Geography                    *string                      `pctsdk:"geography"`
+	NonBreakingChangesPreference *string                      `pctsdk:"non_breaking_changes_preference"`
+	NotifySchemaChanges          *bool                        `pctsdk:"notify_schema_changes"`
+	OperationIDs                 []string                     `pctsdk:"operation_ids"`
+	SyncCatalog                  []connSyncCatalogStreamModel `pctsdk:"sync_catalog"`
+	AdoptExisting                *bool                        `pctsdk:"adopt_existing"`
 	// OperatorConfiguration connOperatorConfig `pctsdk:"operator_configuration"`
 }
 
@@ -40,6 +50,16 @@ type connScheduleDataCron struct {
 	CronTimeZone   string `pctsdk:"cron_time_zone"`
 }
 
+type connSyncCatalogStreamModel struct {
+	StreamName          string     `pctsdk:"stream_name"`
+	StreamNamespace     *string    `pctsdk:"stream_namespace"`
+	SyncMode            *string    `pctsdk:"sync_mode"`
+	DestinationSyncMode *string    `pctsdk:"destination_sync_mode"`
+	CursorField         []string   `pctsdk:"cursor_field"`
+	PrimaryKey          [][]string `pctsdk:"primary_key"`
+	AliasName           *string    `pctsdk:"alias_name"`
+}
+
 // type connOperatorConfig struct {
 // 	OperatorType  string                          `pctsdk:"operator_type"`
 // 	Normalization connOperatorConfigNormalization `pctsdk:"normalization"`
@@ -93,19 +113,11 @@ func (r *connectionResource) Configure(req *schema.ServiceRequest) *schema.Servi
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -121,17 +133,23 @@ func (r *connectionResource) Schema() *schema.ServiceResponse {
 				Required:    true,
 			},
 			"source_id": &schema.StringAttribute{
-				Description: "Source ID",
+				Description: "Source ID. Changing it replaces the connection",
 				Required:    true,
 			},
 			"destination_id": &schema.StringAttribute{
-				Description: "Destination ID",
+				Description: "Destination ID. Changing it replaces the connection",
 				Required:    true,
 			},
 			"connection_id": &schema.StringAttribute{
 				Description: "Connection ID",
 				Computed:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing connection with the same name between the source and destination " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several connections match. Defaults to false.",
+				Optional: true,
+			},
 			"status": &schema.StringAttribute{
 				Description: "Status",
 				Required:    true,
@@ -175,6 +193,104 @@ func (r *connectionResource) Schema() *schema.ServiceResponse {
 					},
 				},
 			},
+			"namespace_definition": &schema.StringAttribute{
+				Description: "Where the destination namespace comes from, one of source, destination or customformat",
+				Optional:    true,
+				Computed:    true,
+			},
+			"namespace_format": &schema.StringAttribute{
+				Description: "Destination namespace format, used with the customformat namespace definition",
+				Optional:    true,
+				Computed:    true,
+			},
+			"prefix": &schema.StringAttribute{
+				Description: "Prefix added to the destination stream names",
+				Optional:    true,
+				Computed:    true,
+			},
+			"geography": &schema.StringAttribute{
+				Description: "Geography where the syncs run",
+				Optional:    true,
+				Computed:    true,
+			},
+			"non_breaking_changes_preference": &schema.StringAttribute{
+				Description: "How non-breaking source schema changes are handled, either ignore or disable",
+				Optional:    true,
+				Computed:    true,
+			},
+			"notify_schema_changes": &schema.BoolAttribute{
+				Description: "Whether to notify on source schema changes",
+				Optional:    true,
+				Computed:    true,
+			},
+			"operation_ids": &schema.ListAttribute{
+				Description: "Operation IDs",
+				Computed:    true,
+				NestedAttribute: &schema.StringAttribute{
+					Description: "Operation ID",
+					Computed:    true,
+				},
+			},
+			"sync_catalog": &schema.ListAttribute{
+				Description: "Selected streams and their sync settings. " +
+					"Streams of the source which are not listed are not synced. " +
+					"When not set, all streams are synced with their discovered defaults. " +
+					"With the public API backend, streams are matched by name without schema discovery, " +
+					"and streams which the server does not configure are reported as an error.",
+				Optional: true,
+				Computed: true,
+				NestedAttribute: &schema.MapAttribute{
+					Description: "Stream",
+					Required:    true,
+					Attributes: map[string]schema.Attribute{
+						"stream_name": &schema.StringAttribute{
+							Description: "Stream name",
+							Required:    true,
+						},
+						"stream_namespace": &schema.StringAttribute{
+							Description: "Stream namespace",
+							Optional:    true,
+						},
+						"sync_mode": &schema.StringAttribute{
+							Description: "Sync mode, either full_refresh or incremental",
+							Optional:    true,
+							Computed:    true,
+						},
+						"destination_sync_mode": &schema.StringAttribute{
+							Description: "Destination sync mode, one of append, overwrite or append_dedup",
+							Optional:    true,
+							Computed:    true,
+						},
+						"cursor_field": &schema.ListAttribute{
+							Description: "Cursor field path",
+							Optional:    true,
+							Computed:    true,
+							NestedAttribute: &schema.StringAttribute{
+								Description: "Field name",
+								Required:    true,
+							},
+						},
+						"primary_key": &schema.ListAttribute{
+							Description: "Primary key field paths",
+							Optional:    true,
+							Computed:    true,
+							NestedAttribute: &schema.ListAttribute{
+								Description: "Primary key field path",
+								Required:    true,
+								NestedAttribute: &schema.StringAttribute{
+									Description: "Field name",
+									Required:    true,
+								},
+							},
+						},
+						"alias_name": &schema.StringAttribute{
+							Description: "Stream name in the destination",
+							Optional:    true,
+							Computed:    true,
+						},
+					},
+				},
+			},
 			// "operator_configuration": &schema.MapAttribute{
 			// 	Description: "Operator configuration",
 			// 	Required:    false,
@@ -268,6 +384,11 @@ func (r *connectionResource) Create(req *schema.ServiceRequest) *schema.ServiceR
 		return schema.ErrorResponse(err)
 	}
 
+	err = r.requireFeatures(plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
 	body := api.ConnectionResource{}
 	body.Name = plan.Name
 	body.SourceID = plan.SourceID
@@ -288,7 +409,31 @@ func (r *connectionResource) Create(req *schema.ServiceRequest) *schema.ServiceR
 
 	body.Status = plan.Status
 
-	connection, err := r.Client.CreateConnectionResource(body)
+	body.NamespaceDefinition = stringValue(plan.NamespaceDefinition)
+	body.NamespaceFormat = stringValue(plan.NamespaceFormat)
+	body.Prefix = stringValue(plan.Prefix)
+	body.Geography = stringValue(plan.Geography)
+	body.NonBreakingChangesPreference = stringValue(plan.NonBreakingChangesPreference)
+	body.NotifySchemaChanges = plan.NotifySchemaChanges
+	body.SyncCatalog = connSyncCatalogToAPI(plan.SyncCatalog)
+
+	// Adopt a matching existing connection, e.g. one left over by a timed out
+	// create, or create a new one.
+	connectionId := ""
+	if boolValue(plan.AdoptExisting) {
+		connectionId, err = adoptableConnectionId(r.Client, plan.Name, plan.SourceID, plan.DestinationID)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var connection api.ConnectionResource
+	if connectionId != "" {
+		body.ConnectionID = connectionId
+		connection, err = r.Client.UpdateConnectionResource(body)
+	} else {
+		connection, err = r.Client.CreateConnectionResource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -300,6 +445,7 @@ func (r *connectionResource) Create(req *schema.ServiceRequest) *schema.ServiceR
 	state.ConnectionID = connection.ConnectionID
 	state.SourceID = connection.SourceID
 	state.DestinationID = connection.DestinationID
+	state.AdoptExisting = plan.AdoptExisting
 
 	state.ScheduleType = connection.ScheduleType
 	state.ScheduleData = connScheduleData{}
@@ -316,6 +462,15 @@ func (r *connectionResource) Create(req *schema.ServiceRequest) *schema.ServiceR
 
 	state.Status = connection.Status
 
+	state.NamespaceDefinition = optionalString(connection.NamespaceDefinition)
+	state.NamespaceFormat = optionalString(connection.NamespaceFormat)
+	state.Prefix = optionalString(connection.Prefix)
+	state.Geography = optionalString(connection.Geography)
+	state.NonBreakingChangesPreference = optionalString(connection.NonBreakingChangesPreference)
+	state.NotifySchemaChanges = connection.NotifySchemaChanges
+	state.OperationIDs = connection.OperationIDs
+	state.SyncCatalog = connSyncCatalogFromAPI(connection.SyncCatalog, plan.SyncCatalog)
+
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
 	if err != nil {
 		return schema.ErrorResponse(err)
@@ -347,6 +502,7 @@ func (r *connectionResource) Read(req *schema.ServiceRequest) *schema.ServiceRes
 			return schema.ErrorResponse(err)
 		}
 
+		prior := state
 		state = connectionResourceModel{}
 
 		// Update state with refreshed value
@@ -355,6 +511,9 @@ func (r *connectionResource) Read(req *schema.ServiceRequest) *schema.ServiceRes
 		state.SourceID = connection.SourceID
 		state.DestinationID = connection.DestinationID
 
+		// Not known to the API, kept from the prior state.
+		state.AdoptExisting = prior.AdoptExisting
+
 		state.ScheduleType = connection.ScheduleType
 		state.ScheduleData = connScheduleData{}
 
@@ -370,6 +529,15 @@ func (r *connectionResource) Read(req *schema.ServiceRequest) *schema.ServiceRes
 
 		state.Status = connection.Status
 
+		state.NamespaceDefinition = optionalString(connection.NamespaceDefinition)
+		state.NamespaceFormat = optionalString(connection.NamespaceFormat)
+		state.Prefix = optionalString(connection.Prefix)
+		state.Geography = optionalString(connection.Geography)
+		state.NonBreakingChangesPreference = optionalString(connection.NonBreakingChangesPreference)
+		state.NotifySchemaChanges = connection.NotifySchemaChanges
+		state.OperationIDs = connection.OperationIDs
+		state.SyncCatalog = connSyncCatalogFromAPI(connection.SyncCatalog, prior.SyncCatalog)
+
 		res.StateID = connection.ConnectionID
 	} else {
 		// No previous state exists.
@@ -394,6 +562,21 @@ func (r *connectionResource) Update(req *schema.ServiceRequest) *schema.ServiceR
 		return schema.ErrorResponse(err)
 	}
 
+	// Airbyte can't move a connection to another source or destination,
+	// so changing those replaces the connection.
+	current, err := r.Client.ReadConnectionResource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.SourceID != plan.SourceID || current.DestinationID != plan.DestinationID {
+		return replaceResource(r, req)
+	}
+
+	err = r.requireFeatures(plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
 	// Generate API request body from plan
 	body := api.ConnectionResource{}
 
@@ -417,6 +600,14 @@ func (r *connectionResource) Update(req *schema.ServiceRequest) *schema.ServiceR
 
 	body.Status = plan.Status
 
+	body.NamespaceDefinition = stringValue(plan.NamespaceDefinition)
+	body.NamespaceFormat = stringValue(plan.NamespaceFormat)
+	body.Prefix = stringValue(plan.Prefix)
+	body.Geography = stringValue(plan.Geography)
+	body.NonBreakingChangesPreference = stringValue(plan.NonBreakingChangesPreference)
+	body.NotifySchemaChanges = plan.NotifySchemaChanges
+	body.SyncCatalog = connSyncCatalogToAPI(plan.SyncCatalog)
+
 	// Update existing source
 	_, err = r.Client.UpdateConnectionResource(body)
 	if err != nil {
@@ -436,6 +627,7 @@ func (r *connectionResource) Update(req *schema.ServiceRequest) *schema.ServiceR
 	state.ConnectionID = connection.ConnectionID
 	state.SourceID = connection.SourceID
 	state.DestinationID = connection.DestinationID
+	state.AdoptExisting = plan.AdoptExisting
 
 	state.ScheduleType = connection.ScheduleType
 	state.ScheduleData = connScheduleData{}
@@ -452,6 +644,15 @@ func (r *connectionResource) Update(req *schema.ServiceRequest) *schema.ServiceR
 
 	state.Status = connection.Status
 
+	state.NamespaceDefinition = optionalString(connection.NamespaceDefinition)
+	state.NamespaceFormat = optionalString(connection.NamespaceFormat)
+	state.Prefix = optionalString(connection.Prefix)
+	state.Geography = optionalString(connection.Geography)
+	state.NonBreakingChangesPreference = optionalString(connection.NonBreakingChangesPreference)
+	state.NotifySchemaChanges = connection.NotifySchemaChanges
+	state.OperationIDs = connection.OperationIDs
+	state.SyncCatalog = connSyncCatalogFromAPI(connection.SyncCatalog, plan.SyncCatalog)
+
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
 	if err != nil {
@@ -475,3 +676,99 @@ func (r *connectionResource) Delete(req *schema.ServiceRequest) *schema.ServiceR
 
 	return &schema.ServiceResponse{}
 }
+
+// Fails fast when the plan uses attributes the server is too old for.
+func (r *connectionResource) requireFeatures(plan connectionResourceModel) error {
+	features := []api.Feature{api.FeatureConnectionSchedule}
+	if plan.Geography != nil {
+		features = append(features, api.FeatureConnectionGeography)
+	}
+	if plan.NonBreakingChangesPreference != nil || plan.NotifySchemaChanges != nil {
+		features = append(features, api.FeatureNonBreakingChangesPreference)
+	}
+
+	for _, f := range features {
+		err := r.Client.RequireFeature(f)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Maps the configured streams to a catalog which only holds
+// the stream configurations, or nil when no streams are configured.
+func connSyncCatalogToAPI(streams []connSyncCatalogStreamModel) *api.ConnSyncCatalog {
+	if len(streams) == 0 {
+		return nil
+	}
+
+	catalog := api.ConnSyncCatalog{}
+	for _, s := range streams {
+		stream := api.ConnSyncCatalogStream{}
+		stream.Stream.Name = s.StreamName
+		stream.Stream.Namespace = stringValue(s.StreamNamespace)
+
+		stream.Config.SyncMode = stringValue(s.SyncMode)
+		stream.Config.DestinationSyncMode = stringValue(s.DestinationSyncMode)
+		stream.Config.CursorField = s.CursorField
+		stream.Config.PrimaryKey = s.PrimaryKey
+		stream.Config.AliasName = stringValue(s.AliasName)
+		stream.Config.Selected = true
+
+		catalog.Streams = append(catalog.Streams, stream)
+	}
+
+	return &catalog
+}
+
+// Maps the selected streams of a catalog to the model.
+// Streams keep the order of the prior streams, new ones follow
+// ordered by namespace and name, so that plans are order-stable.
+func connSyncCatalogFromAPI(catalog *api.ConnSyncCatalog, prior []connSyncCatalogStreamModel) []connSyncCatalogStreamModel {
+	streams := []connSyncCatalogStreamModel{}
+	if catalog == nil {
+		return streams
+	}
+
+	for _, s := range catalog.Streams {
+		if !s.Config.Selected {
+			continue
+		}
+
+		m := connSyncCatalogStreamModel{}
+		m.StreamName = s.Stream.Name
+		m.StreamNamespace = optionalString(s.Stream.Namespace)
+		m.SyncMode = optionalString(s.Config.SyncMode)
+		m.DestinationSyncMode = optionalString(s.Config.DestinationSyncMode)
+		m.CursorField = s.Config.CursorField
+		m.PrimaryKey = s.Config.PrimaryKey
+		m.AliasName = optionalString(s.Config.AliasName)
+
+		streams = append(streams, m)
+	}
+
+	position := func(m connSyncCatalogStreamModel) int {
+		for i, p := range prior {
+			if p.StreamName == m.StreamName && stringValue(p.StreamNamespace) == stringValue(m.StreamNamespace) {
+				return i
+			}
+		}
+		return len(prior)
+	}
+
+	sort.SliceStable(streams, func(i, j int) bool {
+		pi, pj := position(streams[i]), position(streams[j])
+		if pi != pj {
+			return pi < pj
+		}
+		ni, nj := stringValue(streams[i].StreamNamespace), stringValue(streams[j].StreamNamespace)
+		if ni != nj {
+			return ni < nj
+		}
+		return streams[i].StreamName < streams[j].StreamName
+	})
+
+	return streams
+}
@@ -0,0 +1,160 @@
+package plugin_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api/airbytetest"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+// Creates a Stripe source and a local CSV destination to connect.
+func createConnectionEnds(t *testing.T, s *airbytetest.Server) (plugintest.State, plugintest.State) {
+	t.Helper()
+
+	source := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceStripeResource()).Create(plugintest.Values{
+		"name":                 "stripe",
+		"source_definition_id": stripeDefinitionId,
+		"workspace_id":         s.WorkspaceId(),
+		"connection_configuration": plugintest.Values{
+			"account_id":    "acct_123",
+			"client_secret": "secret",
+			"start_date":    "2017-01-25T00:00:00Z",
+		},
+	})
+	destination := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewDestinationLocalCSVResource()).Create(plugintest.Values{
+		"name":                      "csv",
+		"destination_definition_id": localCSVDefinitionId,
+		"workspace_id":              s.WorkspaceId(),
+		"connection_configuration": plugintest.Values{
+			"destination_path": "/local/stripe",
+			"delimiter_type":   plugintest.Values{"delimiter": "\\u002c"},
+		},
+	})
+	return source, destination
+}
+
+func connectionConfig(name string, sourceId string, destinationId string) plugintest.Values {
+	return plugintest.Values{
+		"name":           name,
+		"source_id":      sourceId,
+		"destination_id": destinationId,
+		"status":         "active",
+		"schedule_type":  "basic",
+		"schedule_data": plugintest.Values{
+			"basic_schedule": plugintest.Values{"time_unit": "hours", "units": 24},
+		},
+		"sync_catalog": []interface{}{
+			plugintest.Values{
+				"stream_name":           "users",
+				"sync_mode":             "full_refresh",
+				"destination_sync_mode": "overwrite",
+			},
+		},
+	}
+}
+
+func TestConnectionResource(t *testing.T) {
+	s := newServer(t)
+	source, destination := createConnectionEnds(t, s)
+
+	update := connectionConfig("connection renamed", source.ID, destination.ID)
+	update["status"] = "inactive"
+
+	plugintest.RunLifecycle(t, plugintest.Lifecycle{
+		ProviderConfig: plugintest.ProviderConfig(s),
+		Resource:       plugin.NewConnectionResource(),
+		Create: plugintest.Step{
+			Config: connectionConfig("connection", source.ID, destination.ID),
+			Check: func(state plugintest.State) error {
+				if got := state.String("sync_catalog.0.stream_name"); got != "users" {
+					return fmt.Errorf("sync_catalog.0.stream_name = %q, want users", got)
+				}
+				if _, ok := state.Attr("sync_catalog.1"); ok {
+					return fmt.Errorf("sync_catalog has more than the configured stream")
+				}
+				return nil
+			},
+		},
+		Update: plugintest.Step{
+			Config: update,
+			Check: func(state plugintest.State) error {
+				if got := state.String("status"); got != "inactive" {
+					return fmt.Errorf("status = %q, want inactive", got)
+				}
+				return nil
+			},
+		},
+		CheckDestroy: func(state plugintest.State) error {
+			if _, ok := s.Connection(state.ID); ok {
+				return fmt.Errorf("connection %s still exists", state.ID)
+			}
+			return nil
+		},
+	})
+}
+
+func TestConnectionResourceAdoptExisting(t *testing.T) {
+	s := newServer(t)
+	source, destination := createConnectionEnds(t, s)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewConnectionResource())
+
+	existing := h.Create(connectionConfig("connection", source.ID, destination.ID))
+
+	config := connectionConfig("connection", source.ID, destination.ID)
+	config["adopt_existing"] = true
+	adopted := h.Create(config)
+	if adopted.ID != existing.ID {
+		t.Errorf("adopted connection %s, want the existing %s", adopted.ID, existing.ID)
+	}
+
+	// Reading must keep adopt_existing, or every plan would show a change.
+	read := h.Read(adopted)
+	if v, _ := read.Attr("adopt_existing"); v != true {
+		t.Errorf("adopt_existing = %v after read, want true", v)
+	}
+}
+
+func TestConnectionResourceReplace(t *testing.T) {
+	s := newServer(t)
+	source, destination := createConnectionEnds(t, s)
+	other, _ := createConnectionEnds(t, s)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewConnectionResource())
+
+	created := h.Create(connectionConfig("connection", source.ID, destination.ID))
+	replaced := h.Update(created, connectionConfig("connection", other.ID, destination.ID))
+	if replaced.ID == created.ID {
+		t.Fatalf("connection ID %s kept, want a new connection for the new source", created.ID)
+	}
+	if _, ok := s.Connection(created.ID); ok {
+		t.Errorf("replaced connection %s still exists", created.ID)
+	}
+}
+
+func TestSourceReplaceWithConnections(t *testing.T) {
+	s := newServer(t)
+	source, destination := createConnectionEnds(t, s)
+	connection := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewConnectionResource()).
+		Create(connectionConfig("connection", source.ID, destination.ID))
+
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceStripeResource())
+	config := plugintest.Values{
+		"name":                 "stripe",
+		"source_definition_id": "00000000-0000-0000-0000-000000000000",
+		"workspace_id":         s.WorkspaceId(),
+		"connection_configuration": plugintest.Values{
+			"account_id":    "acct_123",
+			"client_secret": "secret",
+			"start_date":    "2017-01-25T00:00:00Z",
+		},
+	}
+	_, err := h.TryUpdate(source, config)
+	if err == nil || !strings.Contains(err.Error(), connection.ID) {
+		t.Fatalf("update error = %v, want an error naming connection %s", err, connection.ID)
+	}
+	if _, ok := s.Connection(connection.ID); !ok {
+		t.Errorf("connection %s was deleted", connection.ID)
+	}
+}
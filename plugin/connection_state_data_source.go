@@ -0,0 +1,274 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Data source implementation.
+// Data sources are served as resources which only ever read
+// from Airbyte, so Create and Update refresh and Delete is a no-op.
+type connectionStateDataSource struct {
+	Client *api.Client
+}
+
+type connectionStateDataSourceModel struct {
+	ConnectionID string                 `pctsdk:"connection_id"`
+	StateType    string                 `pctsdk:"state_type"`
+	State        string                 `pctsdk:"state"`
+	SharedState  string                 `pctsdk:"shared_state"`
+	StreamStates []connStreamStateModel `pctsdk:"stream_states"`
+}
+
+type connStreamStateModel struct {
+	StreamName      string  `pctsdk:"stream_name"`
+	StreamNamespace *string `pctsdk:"stream_namespace"`
+	State           string  `pctsdk:"state"`
+}
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ schema.ResourceService = &connectionStateDataSource{}
+)
+
+// Helper function to return a data source service instance.
+func NewConnectionStateDataSource() schema.ResourceService {
+	return &connectionStateDataSource{}
+}
+
+// Metadata returns the data source type name.
+// It is always provider name + "_" + data source type name.
+func (r *connectionStateDataSource) Metadata(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{
+		TypeName: req.TypeName + "_connection_state",
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (r *connectionStateDataSource) Configure(req *schema.ServiceRequest) *schema.ServiceResponse {
+	if req.ResourceData == "" {
+		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
+	}
+
+	client, err := configuredClient(req.ResourceData)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	r.Client = client
+
+	return &schema.ServiceResponse{}
+}
+
+// Schema defines the schema for the data source.
+func (r *connectionStateDataSource) Schema() *schema.ServiceResponse {
+	s := &schema.Schema{
+		Description: "Connection state data source for Airbyte",
+		Attributes: map[string]schema.Attribute{
+			"connection_id": &schema.StringAttribute{
+				Description: "Connection ID",
+				Required:    true,
+			},
+			"state_type": &schema.StringAttribute{
+				Description: "State type, one of legacy, global, stream or not_set",
+				Computed:    true,
+			},
+			"state": &schema.StringAttribute{
+				Description: "Legacy state blob as JSON",
+				Computed:    true,
+			},
+			"shared_state": &schema.StringAttribute{
+				Description: "Shared state blob of a global state as JSON",
+				Computed:    true,
+			},
+			"stream_states": &schema.ListAttribute{
+				Description: "Per-stream states, including the streams of a global state",
+				Computed:    true,
+				NestedAttribute: &schema.MapAttribute{
+					Description: "Stream state",
+					Computed:    true,
+					Attributes: map[string]schema.Attribute{
+						"stream_name": &schema.StringAttribute{
+							Description: "Stream name",
+							Computed:    true,
+						},
+						"stream_namespace": &schema.StringAttribute{
+							Description: "Stream namespace",
+							Computed:    true,
+						},
+						"state": &schema.StringAttribute{
+							Description: "Stream state blob as JSON",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	sEnc, err := fwhelpers.Encode(s)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		SchemaContents: sEnc,
+	}
+}
+
+// Create reads the data source for the first time.
+func (r *connectionStateDataSource) Create(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var plan connectionStateDataSourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return r.refresh(plan.ConnectionID)
+}
+
+// Read refreshes the data source information.
+func (r *connectionStateDataSource) Read(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var state connectionStateDataSourceModel
+
+	// Get current state
+	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	if req.StateID == "" {
+		// No previous state exists.
+		res := schema.ServiceResponse{}
+		stateEnc, err := fwhelpers.PackModel(nil, &state)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+		res.StateContents = stateEnc
+
+		return &res
+	}
+
+	return r.refresh(req.StateID)
+}
+
+func (r *connectionStateDataSource) Update(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var plan connectionStateDataSourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return r.refresh(plan.ConnectionID)
+}
+
+// Delete only removes the data source from state.
+func (r *connectionStateDataSource) Delete(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{}
+}
+
+func (r *connectionStateDataSource) refresh(connectionId string) *schema.ServiceResponse {
+	connState, err := r.Client.ReadConnectionState(connectionId)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	state := connectionStateDataSourceModel{}
+	state.ConnectionID = connectionId
+	state.StateType = connState.StateType
+
+	state.State, err = normalizeJSONBlob(connState.State)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	streamStates := connState.StreamState
+	if connState.GlobalState != nil {
+		state.SharedState, err = normalizeJSONBlob(connState.GlobalState.SharedState)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+		streamStates = connState.GlobalState.StreamStates
+	}
+
+	state.StreamStates, err = connStreamStatesFromAPI(streamStates)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.ConnectionID,
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Re-encodes a JSON blob so that equivalent values always
+// produce the same string, regardless of key order or spacing.
+func normalizeJSONBlob(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var v interface{}
+	err := json.Unmarshal(raw, &v)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// Maps API stream states to the model, ordered by namespace and name.
+func connStreamStatesFromAPI(streamStates []api.ConnectionStreamState) ([]connStreamStateModel, error) {
+	models := []connStreamStateModel{}
+	for _, s := range streamStates {
+		blob, err := normalizeJSONBlob(s.StreamState)
+		if err != nil {
+			return nil, err
+		}
+
+		m := connStreamStateModel{}
+		m.StreamName = s.StreamDescriptor.Name
+		if s.StreamDescriptor.Namespace != "" {
+			namespace := s.StreamDescriptor.Namespace
+			m.StreamNamespace = &namespace
+		}
+		m.State = blob
+
+		models = append(models, m)
+	}
+
+	sort.SliceStable(models, func(i, j int) bool {
+		ni, nj := streamNamespace(models[i]), streamNamespace(models[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return models[i].StreamName < models[j].StreamName
+	})
+
+	return models, nil
+}
+
+func streamNamespace(m connStreamStateModel) string {
+	if m.StreamNamespace == nil {
+		return ""
+	}
+	return *m.StreamNamespace
+}
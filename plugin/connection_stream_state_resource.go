@@ -0,0 +1,317 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Resource implementation.
+// Overrides the cursor state of the declared streams of a connection.
+// Streams which are not declared keep their state as is.
+type connectionStreamStateResource struct {
+	Client *api.Client
+}
+
+type connectionStreamStateResourceModel struct {
+	ConnectionID string                 `pctsdk:"connection_id"`
+	StateType    string                 `pctsdk:"state_type"`
+	StreamStates []connStreamStateModel `pctsdk:"stream_states"`
+}
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ schema.ResourceService = &connectionStreamStateResource{}
+)
+
+// Helper function to return a resource service instance.
+func NewConnectionStreamStateResource() schema.ResourceService {
+	return &connectionStreamStateResource{}
+}
+
+// Metadata returns the resource type name.
+// It is always provider name + "_" + resource type name.
+func (r *connectionStreamStateResource) Metadata(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{
+		TypeName: req.TypeName + "_connection_stream_state",
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *connectionStreamStateResource) Configure(req *schema.ServiceRequest) *schema.ServiceResponse {
+	if req.ResourceData == "" {
+		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
+	}
+
+	client, err := configuredClient(req.ResourceData)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	r.Client = client
+
+	return &schema.ServiceResponse{}
+}
+
+// Schema defines the schema for the resource.
+func (r *connectionStreamStateResource) Schema() *schema.ServiceResponse {
+	s := &schema.Schema{
+		Description: "Per-stream cursor state override for an Airbyte connection",
+		Attributes: map[string]schema.Attribute{
+			"connection_id": &schema.StringAttribute{
+				Description: "Connection ID",
+				Required:    true,
+			},
+			"state_type": &schema.StringAttribute{
+				Description: "State type of the connection after the override, either global or stream",
+				Computed:    true,
+			},
+			"stream_states": &schema.ListAttribute{
+				Description: "Cursor states to apply, one per stream",
+				Required:    true,
+				NestedAttribute: &schema.MapAttribute{
+					Description: "Stream state",
+					Required:    true,
+					Attributes: map[string]schema.Attribute{
+						"stream_name": &schema.StringAttribute{
+							Description: "Stream name",
+							Required:    true,
+						},
+						"stream_namespace": &schema.StringAttribute{
+							Description: "Stream namespace",
+							Optional:    true,
+						},
+						"state": &schema.StringAttribute{
+							Description: "Stream state blob as JSON, e.g. {\"created\": 1672531200}",
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	sEnc, err := fwhelpers.Encode(s)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		SchemaContents: sEnc,
+	}
+}
+
+// Create applies the declared stream states.
+func (r *connectionStreamStateResource) Create(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var plan connectionStreamStateResourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return r.apply(plan)
+}
+
+// Read resource information
+func (r *connectionStreamStateResource) Read(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var state connectionStreamStateResourceModel
+
+	// Get current state
+	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	res := schema.ServiceResponse{}
+
+	if req.StateID != "" {
+		// Query using existing previous state.
+		connState, err := r.Client.ReadConnectionState(req.StateID)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+
+		// Update state with refreshed value
+		state.ConnectionID = req.StateID
+		state.StateType = connState.StateType
+		state.StreamStates, err = selectConnStreamStates(connState, state.StreamStates)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+
+		res.StateID = state.ConnectionID
+	} else {
+		// No previous state exists.
+		res.StateID = ""
+	}
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	res.StateContents = stateEnc
+
+	return &res
+}
+
+// Update applies the declared stream states again.
+func (r *connectionStreamStateResource) Update(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var plan connectionStreamStateResourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return r.apply(plan)
+}
+
+// Delete only removes the override from state.
+// The connection keeps its current cursor state.
+func (r *connectionStreamStateResource) Delete(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{}
+}
+
+func (r *connectionStreamStateResource) apply(plan connectionStreamStateResourceModel) *schema.ServiceResponse {
+	err := r.Client.RequireFeature(api.FeatureConnectionStateUpdate)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	declared := []api.ConnectionStreamState{}
+	for _, s := range plan.StreamStates {
+		var blob map[string]interface{}
+		err = json.Unmarshal([]byte(s.State), &blob)
+		if err != nil {
+			return schema.ErrorResponse(fmt.Errorf(
+				"state of stream %s must be a JSON object: %s", s.StreamName, err.Error(),
+			))
+		}
+
+		declared = append(declared, api.ConnectionStreamState{
+			StreamDescriptor: api.StreamDescriptor{
+				Name:      s.StreamName,
+				Namespace: streamNamespace(s),
+			},
+			StreamState: json.RawMessage(s.State),
+		})
+	}
+
+	current, err := r.Client.ReadConnectionState(plan.ConnectionID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	body := api.ConnectionStateCreateOrUpdate{}
+	body.ConnectionId = plan.ConnectionID
+	body.ConnectionState = api.ConnectionState{}
+	body.ConnectionState.ConnectionId = plan.ConnectionID
+
+	switch current.StateType {
+	case "legacy":
+		return schema.ErrorResponse(fmt.Errorf(
+			"connection %s uses legacy state which has no per-stream cursors.\n"+
+				"Reset the connection or run a sync to migrate it to per-stream state first.",
+			plan.ConnectionID,
+		))
+	case "global":
+		// Keep the shared state, e.g. CDC offsets, as is.
+		body.ConnectionState.StateType = "global"
+		body.ConnectionState.GlobalState = &api.ConnectionGlobalState{}
+		if current.GlobalState != nil {
+			body.ConnectionState.GlobalState.SharedState = current.GlobalState.SharedState
+			body.ConnectionState.GlobalState.StreamStates = mergeConnStreamStates(
+				current.GlobalState.StreamStates, declared,
+			)
+		} else {
+			body.ConnectionState.GlobalState.StreamStates = declared
+		}
+	default:
+		body.ConnectionState.StateType = "stream"
+		body.ConnectionState.StreamState = mergeConnStreamStates(current.StreamState, declared)
+	}
+
+	connState, err := r.Client.CreateOrUpdateConnectionState(body)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Update resource state with response body
+	state := connectionStreamStateResourceModel{}
+	state.ConnectionID = plan.ConnectionID
+	state.StateType = connState.StateType
+	state.StreamStates, err = selectConnStreamStates(connState, plan.StreamStates)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.ConnectionID,
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Replaces the state of the declared streams and keeps all others.
+func mergeConnStreamStates(current []api.ConnectionStreamState, declared []api.ConnectionStreamState) []api.ConnectionStreamState {
+	merged := []api.ConnectionStreamState{}
+	for _, c := range current {
+		overridden := false
+		for _, d := range declared {
+			if c.StreamDescriptor == d.StreamDescriptor {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			merged = append(merged, c)
+		}
+	}
+
+	return append(merged, declared...)
+}
+
+// Picks the states of the wanted streams out of the connection state.
+// Streams missing on the server are left out, so that they show up as drift.
+func selectConnStreamStates(connState api.ConnectionState, wanted []connStreamStateModel) ([]connStreamStateModel, error) {
+	streamStates := connState.StreamState
+	if connState.GlobalState != nil {
+		streamStates = connState.GlobalState.StreamStates
+	}
+
+	all, err := connStreamStatesFromAPI(streamStates)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := []connStreamStateModel{}
+	for _, w := range wanted {
+		for _, s := range all {
+			if s.StreamName == w.StreamName && streamNamespace(s) == streamNamespace(w) {
+				// Keep the namespace as declared, unset and empty are the same.
+				s.StreamNamespace = w.StreamNamespace
+
+				// Keep the declared formatting of an unchanged state.
+				declared, err := normalizeJSONBlob(json.RawMessage(w.State))
+				if err == nil && declared == s.State {
+					s.State = w.State
+				}
+
+				selected = append(selected, s)
+				break
+			}
+		}
+	}
+
+	return selected, nil
+}
@@ -21,6 +21,7 @@ type destinationLocalCSVResourceModel struct {
 	DestinationDefinitionId string                             `pctsdk:"destination_definition_id"`
 	WorkspaceId             string                             `pctsdk:"workspace_id"`
 	ConnectionConfiguration destinationLocalCSVConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                              `pctsdk:"adopt_existing"`
 }
 type destinationLocalCSVConnConfigModel struct {
 	DestinationPath string                          `pctsdk:"destination_path"`
@@ -55,19 +56,11 @@ func (r *destinationLocalCSVResource) Configure(req *schema.ServiceRequest) *sch
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -88,13 +81,19 @@ func (r *destinationLocalCSVResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"destination_definition_id": &schema.StringAttribute{
-				Description: "Destination Definition ID",
+				Description: "Destination Definition ID. Changing it replaces the destination, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the destination, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing destination with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several destinations match. Defaults to false.",
+				Optional: true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
@@ -151,8 +150,25 @@ func (r *destinationLocalCSVResource) Create(req *schema.ServiceRequest) *schema
 	body.ConnectionConfiguration.DelimiterType = api.DestinationDelimiterConfigModel{}
 	body.ConnectionConfiguration.DelimiterType.Delimiter = plan.ConnectionConfiguration.DelimiterType.Delimiter
 
-	// Create new source
-	destination, err := r.Client.CreateLocalCSVDestination(body)
+	// Adopt a matching existing destination, e.g. one left over by a timed out
+	// create, or create a new one.
+	destinationId := ""
+	if boolValue(plan.AdoptExisting) {
+		destinationId, err = adoptableDestinationId(r.Client, plan.WorkspaceId, plan.Name, plan.DestinationDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var destination api.DestinationLocalCSV
+	if destinationId != "" {
+		body.DestinationId = destinationId
+		body.DestinationDefinitionId = ""
+		body.WorkspaceId = ""
+		destination, err = r.Client.UpdateLocalCSVDestination(body)
+	} else {
+		destination, err = r.Client.CreateLocalCSVDestination(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -163,6 +179,7 @@ func (r *destinationLocalCSVResource) Create(req *schema.ServiceRequest) *schema
 	state.DestinationDefinitionId = destination.DestinationDefinitionId
 	state.DestinationId = destination.DestinationId
 	state.WorkspaceId = destination.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
 
 	state.ConnectionConfiguration = destinationLocalCSVConnConfigModel{}
 	state.ConnectionConfiguration.DestinationPath = destination.ConnectionConfiguration.DestinationPath
@@ -239,6 +256,16 @@ func (r *destinationLocalCSVResource) Update(req *schema.ServiceRequest) *schema
 		return schema.ErrorResponse(err)
 	}
 
+	// Airbyte can't move a destination to another workspace or definition,
+	// so changing those replaces the destination.
+	current, err := r.Client.ReadDestination(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.DestinationDefinitionId != plan.DestinationDefinitionId {
+		return replaceActor(r, req, r.Client, "destination", current.WorkspaceId)
+	}
+
 	// Generate API request body from plan
 	body := api.DestinationLocalCSV{}
 	body.Name = plan.Name
@@ -267,6 +294,7 @@ func (r *destinationLocalCSVResource) Update(req *schema.ServiceRequest) *schema
 	state.DestinationDefinitionId = destination.DestinationDefinitionId
 	state.DestinationId = destination.DestinationId
 	state.WorkspaceId = destination.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
 
 	state.ConnectionConfiguration = destinationLocalCSVConnConfigModel{}
 	state.ConnectionConfiguration.DestinationPath = destination.ConnectionConfiguration.DestinationPath
@@ -0,0 +1,172 @@
+package plugin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+)
+
+// Block of a connector configuration holding one of several variants,
+// like the credentials of the different auth methods. Each variant is a
+// nested block, of which exactly one must be set, and Airbyte tells them
+// apart by the Discriminator property, like auth_type.
+type oneOf struct {
+	// Attribute path, e.g. connection_configuration.credentials, for errors.
+	Path          string
+	Discriminator string
+	Variants      []oneOfVariant
+}
+
+// Variant of a oneOf block, configured as the nested block named Key and
+// sent to Airbyte with the discriminator set to Value. A variant without
+// attributes is configured as a bool set to true.
+type oneOfVariant struct {
+	Key         string
+	Value       string
+	Description string
+	Attributes  map[string]schema.Attribute
+}
+
+// Returns the attribute of the block, with an attribute per variant.
+func (o oneOf) attribute(description string, required bool) *schema.MapAttribute {
+	a := &schema.MapAttribute{
+		Description: description,
+		Required:    required,
+		Optional:    !required,
+		Attributes:  map[string]schema.Attribute{},
+	}
+	for _, v := range o.Variants {
+		a.ExactlyOneOf = append(a.ExactlyOneOf, v.Key)
+
+		// Like the connection schedule_data blocks, each variant is marked
+		// required and ExactlyOneOf makes only one of them needed.
+		if v.Attributes == nil {
+			a.Attributes[v.Key] = &schema.BoolAttribute{
+				Description: fmt.Sprintf("Set to true to set %s to %s", o.Discriminator, v.Value),
+				Required:    true,
+			}
+			continue
+		}
+		d := v.Description
+		if d == "" {
+			d = fmt.Sprintf("Sets %s to %s", o.Discriminator, v.Value)
+		}
+		a.Attributes[v.Key] = &schema.MapAttribute{
+			Description: d,
+			Required:    true,
+			Attributes:  v.Attributes,
+		}
+	}
+	return a
+}
+
+// Checks that exactly one variant is set, where set tells for each of
+// the variants, in order, whether it is set.
+func (o oneOf) validate(set ...bool) error {
+	keys := []string{}
+	for _, v := range o.Variants {
+		keys = append(keys, v.Key)
+	}
+	return validateExactlyOneOf(o.Path, keys, set...)
+}
+
+// Returns the state contents with the block upgraded from schema version
+// 0, where the variant attributes were flat siblings of the discriminator,
+// like credentials.credentials_title next to credentials.access_token, to
+// the nested block of the variant the discriminator names. Contents of the
+// current version are returned as is.
+func (o oneOf) upgradeState(contents string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(contents)
+	if err != nil {
+		return "", err
+	}
+	state, err := ctyjson.Unmarshal(b, cty.DynamicPseudoType)
+	if err != nil {
+		return "", err
+	}
+
+	upgraded, changed, err := o.upgradeValue(state, strings.Split(o.Path, "."))
+	if err != nil || !changed {
+		return contents, err
+	}
+	return fwhelpers.PackModel(&upgraded, nil)
+}
+
+// Upgrades the block at path within v, and reports whether it changed.
+func (o oneOf) upgradeValue(v cty.Value, path []string) (cty.Value, bool, error) {
+	if v.IsNull() || !v.IsKnown() || !v.Type().IsObjectType() {
+		return v, false, nil
+	}
+
+	if len(path) > 0 {
+		if !v.Type().HasAttribute(path[0]) {
+			return v, false, nil
+		}
+		attrs := v.AsValueMap()
+		next, changed, err := o.upgradeValue(attrs[path[0]], path[1:])
+		if err != nil || !changed {
+			return v, false, err
+		}
+		attrs[path[0]] = next
+		return cty.ObjectVal(attrs), true, nil
+	}
+
+	if !v.Type().HasAttribute(o.Discriminator) {
+		return v, false, nil
+	}
+
+	discriminator := v.GetAttr(o.Discriminator)
+	value := ""
+	if !discriminator.IsNull() && discriminator.Type() == cty.String {
+		value = discriminator.AsString()
+	}
+	for _, variant := range o.Variants {
+		if variant.Value != value {
+			continue
+		}
+
+		if variant.Attributes == nil {
+			return cty.ObjectVal(map[string]cty.Value{variant.Key: cty.True}), true, nil
+		}
+		attrs := map[string]cty.Value{}
+		for name, a := range variant.Attributes {
+			if v.Type().HasAttribute(name) {
+				attrs[name] = v.GetAttr(name)
+				continue
+			}
+			attrs[name] = cty.NullVal(attributeType(a))
+		}
+		return cty.ObjectVal(map[string]cty.Value{variant.Key: cty.ObjectVal(attrs)}), true, nil
+	}
+
+	return v, false, fmt.Errorf(
+		"unable to upgrade %s in state written by an earlier provider version, %s %q is not one of %s. "+
+			"Remove the resource from the state and import it again",
+		o.Path, o.Discriminator, value, strings.Join(o.values(), ", "),
+	)
+}
+
+func (o oneOf) values() []string {
+	values := []string{}
+	for _, v := range o.Variants {
+		values = append(values, v.Value)
+	}
+	return values
+}
+
+// Returns the state type of a primitive attribute.
+func attributeType(a schema.Attribute) cty.Type {
+	switch a.(type) {
+	case *schema.BoolAttribute:
+		return cty.Bool
+	case *schema.IntAttribute, *schema.FloatAttribute:
+		return cty.Number
+	default:
+		return cty.String
+	}
+}
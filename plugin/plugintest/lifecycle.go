@@ -0,0 +1,86 @@
+package plugintest
+
+import (
+	"github.com/zipstack/pct-plugin-framework/schema"
+)
+
+// Step is a configuration applied to the resource, with a check of the
+// resulting state.
+type Step struct {
+	Config Values
+	Check  func(s State) error
+}
+
+// Lifecycle describes a resource lifecycle test.
+type Lifecycle struct {
+	// Provider configuration, see ProviderConfig for a fake Airbyte.
+	ProviderConfig Values
+
+	// Resource under test, e.g. plugin.NewSourceStripeResource().
+	Resource schema.ResourceService
+
+	// Configuration the resource is created with.
+	Create Step
+
+	// Configuration the resource is updated to. The update is skipped
+	// when Config is nil.
+	Update Step
+
+	// Optional check run after the resource is deleted, e.g. that the
+	// fake Airbyte no longer has it.
+	CheckDestroy func(s State) error
+}
+
+// RunLifecycle configures the resource and runs Create, Read, Update,
+// Read and Delete in order, like the engine would. It fails when a
+// method returns an error, a state ID changes on read or a check fails.
+func RunLifecycle(t TB, l Lifecycle) {
+	t.Helper()
+
+	h := New(t, l.ProviderConfig, l.Resource)
+
+	state := h.Create(l.Create.Config)
+	t.Logf("%s created with ID %s", h.TypeName(), state.ID)
+	state = h.readAndCheck(state, l.Create)
+
+	if l.Update.Config != nil {
+		updated := h.Update(state, l.Update.Config)
+		t.Logf("%s updated with ID %s", h.TypeName(), updated.ID)
+		if updated.ID != state.ID {
+			t.Fatalf("%s update changed ID from %s to %s", h.TypeName(), state.ID, updated.ID)
+		}
+		state = h.readAndCheck(updated, l.Update)
+	}
+
+	h.Delete(state)
+	t.Logf("%s deleted with ID %s", h.TypeName(), state.ID)
+
+	if l.CheckDestroy != nil {
+		if err := l.CheckDestroy(state); err != nil {
+			t.Fatalf("%s destroy check: %s", h.TypeName(), err.Error())
+		}
+	}
+}
+
+// Checks the state returned by an apply and the state read back.
+func (h *Harness) readAndCheck(applied State, step Step) State {
+	h.t.Helper()
+
+	if step.Check != nil {
+		if err := step.Check(applied); err != nil {
+			h.t.Fatalf("%s check after apply: %s", h.typeName, err.Error())
+		}
+	}
+
+	read := h.Read(applied)
+	if read.ID != applied.ID {
+		h.t.Fatalf("%s read changed ID from %s to %s", h.typeName, applied.ID, read.ID)
+	}
+
+	if step.Check != nil {
+		if err := step.Check(read); err != nil {
+			h.t.Fatalf("%s check after read: %s", h.typeName, err.Error())
+		}
+	}
+	return read
+}
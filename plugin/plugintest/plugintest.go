@@ -0,0 +1,316 @@
+// Package plugintest drives the provider resources through their
+// schema.ResourceService methods, the way the engine does, so the
+// resource lifecycle can be checked against a local fake Airbyte.
+package plugintest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api/airbytetest"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+)
+
+// TB is the part of testing.TB used by the harness.
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...interface{})
+	Logf(format string, args ...interface{})
+}
+
+// Values holds attribute values keyed by attribute name. Nested
+// attributes are maps, list attributes are slices.
+type Values map[string]interface{}
+
+// State is a resource state returned by the resource.
+type State struct {
+	ID       string
+	Contents string
+	Values   Values
+}
+
+// Attr returns the state value at a dotted path, like "name" or
+// "sync_catalog.streams.0.config.sync_mode".
+func (s State) Attr(path string) (interface{}, bool) {
+	var v interface{} = map[string]interface{}(s.Values)
+	for _, key := range strings.Split(path, ".") {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			next, ok := t[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, false
+			}
+			v = t[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// String returns the string state value at a dotted path, or "" when
+// it is not set.
+func (s State) String(path string) string {
+	v, _ := s.Attr(path)
+	str, _ := v.(string)
+	return str
+}
+
+// ProviderConfig returns the provider configuration for a fake Airbyte.
+func ProviderConfig(s *airbytetest.Server) Values {
+	return Values{
+		"host":     s.Host(),
+		"username": airbytetest.Username,
+		"password": airbytetest.Password,
+	}
+}
+
+// Harness calls the ResourceService methods of one resource.
+type Harness struct {
+	t        TB
+	resource schema.ResourceService
+	schema   *schema.Schema
+	typeName string
+}
+
+// New configures the provider with providerConfig and the resource with
+// the provider resource data.
+func New(t TB, providerConfig Values, resource schema.ResourceService) *Harness {
+	t.Helper()
+
+	provider := plugin.NewProvider()
+	providerSchema, err := decodeSchema(provider.Schema())
+	if err != nil {
+		t.Fatalf("provider schema: %s", err.Error())
+	}
+	ty, err := configType(providerSchema.Attributes, nil, providerConfig)
+	if err != nil {
+		t.Fatalf("provider schema: %s", err.Error())
+	}
+	config, err := packValues(ty, providerConfig)
+	if err != nil {
+		t.Fatalf("provider config: %s", err.Error())
+	}
+
+	res := provider.Configure(&schema.ServiceRequest{ConfigContents: config})
+	if res.ErrorsContents != "" {
+		t.Fatalf("provider configure: %s", res.ErrorsContents)
+	}
+
+	typeName := resource.Metadata(&schema.ServiceRequest{
+		TypeName: provider.Metadata(&schema.ServiceRequest{}).TypeName,
+	}).TypeName
+
+	s, err := decodeSchema(resource.Schema())
+	if err != nil {
+		t.Fatalf("%s schema: %s", typeName, err.Error())
+	}
+
+	res = resource.Configure(&schema.ServiceRequest{ResourceData: res.ResourceData})
+	if res.ErrorsContents != "" {
+		t.Fatalf("%s configure: %s", typeName, res.ErrorsContents)
+	}
+
+	return &Harness{
+		t:        t,
+		resource: resource,
+		schema:   s,
+		typeName: typeName,
+	}
+}
+
+// TypeName returns the full type name of the resource.
+func (h *Harness) TypeName() string {
+	return h.typeName
+}
+
+// Create creates the resource from config and returns its state.
+func (h *Harness) Create(config Values) State {
+	h.t.Helper()
+
+	state, err := h.TryCreate(config)
+	if err != nil {
+		h.t.Fatalf("%s create: %s", h.typeName, err.Error())
+	}
+	return state
+}
+
+// TryCreate is like Create, but returns the resource error.
+func (h *Harness) TryCreate(config Values) (State, error) {
+	h.t.Helper()
+
+	plan := h.plan(config, nil)
+	res := h.resource.Create(&schema.ServiceRequest{
+		TypeName:     h.typeName,
+		PlanContents: plan,
+	})
+	return h.state(res)
+}
+
+// Read refreshes the state of the resource.
+func (h *Harness) Read(prior State) State {
+	h.t.Helper()
+
+	state, err := h.TryRead(prior)
+	if err != nil {
+		h.t.Fatalf("%s read: %s", h.typeName, err.Error())
+	}
+	return state
+}
+
+// TryRead is like Read, but returns the resource error.
+func (h *Harness) TryRead(prior State) (State, error) {
+	h.t.Helper()
+
+	res := h.resource.Read(&schema.ServiceRequest{
+		TypeName:      h.typeName,
+		StateID:       prior.ID,
+		StateContents: prior.Contents,
+	})
+	return h.state(res)
+}
+
+// Update updates the resource to config and returns its new state.
+// Computed attributes not set in config keep their prior values.
+func (h *Harness) Update(prior State, config Values) State {
+	h.t.Helper()
+
+	state, err := h.TryUpdate(prior, config)
+	if err != nil {
+		h.t.Fatalf("%s update: %s", h.typeName, err.Error())
+	}
+	return state
+}
+
+// TryUpdate is like Update, but returns the resource error.
+func (h *Harness) TryUpdate(prior State, config Values) (State, error) {
+	h.t.Helper()
+
+	plan := h.plan(config, prior.Values)
+	res := h.resource.Update(&schema.ServiceRequest{
+		TypeName:      h.typeName,
+		PlanID:        prior.ID,
+		PlanContents:  plan,
+		StateID:       prior.ID,
+		StateContents: prior.Contents,
+	})
+	return h.state(res)
+}
+
+// Delete deletes the resource.
+func (h *Harness) Delete(prior State) {
+	h.t.Helper()
+
+	err := h.TryDelete(prior)
+	if err != nil {
+		h.t.Fatalf("%s delete: %s", h.typeName, err.Error())
+	}
+}
+
+// TryDelete is like Delete, but returns the resource error.
+func (h *Harness) TryDelete(prior State) error {
+	h.t.Helper()
+
+	res := h.resource.Delete(&schema.ServiceRequest{
+		TypeName:      h.typeName,
+		StateID:       prior.ID,
+		StateContents: prior.Contents,
+	})
+	if res.ErrorsContents != "" {
+		return fmt.Errorf("%s", res.ErrorsContents)
+	}
+	return nil
+}
+
+// Builds the plan contents from config. Computed attributes missing in
+// config take their prior value, or a zero value when there is none.
+func (h *Harness) plan(config Values, prior Values) string {
+	h.t.Helper()
+
+	values := map[string]interface{}{}
+	for name, a := range h.schema.Attributes {
+		if v, ok := config[name]; ok {
+			values[name] = v
+			continue
+		}
+		if !a.IsComputed() {
+			continue
+		}
+		if v, ok := prior[name]; ok && v != nil {
+			values[name] = v
+			continue
+		}
+		ty, err := attributeType(a)
+		if err != nil {
+			h.t.Fatalf("%s schema: %s: %s", h.typeName, name, err.Error())
+		}
+		values[name] = zeroValue(ty)
+	}
+
+	err := checkConfig(h.schema.Attributes, nil, config, "")
+	if err != nil {
+		h.t.Fatalf("%s config: %s", h.typeName, err.Error())
+	}
+
+	ty, err := configType(h.schema.Attributes, nil, values)
+	if err != nil {
+		h.t.Fatalf("%s schema: %s", h.typeName, err.Error())
+	}
+
+	plan, err := packValues(ty, values)
+	if err != nil {
+		h.t.Fatalf("%s plan: %s", h.typeName, err.Error())
+	}
+	return plan
+}
+
+// Returns the state of a resource response.
+func (h *Harness) state(res *schema.ServiceResponse) (State, error) {
+	h.t.Helper()
+
+	if res.ErrorsContents != "" {
+		return State{}, fmt.Errorf("%s", res.ErrorsContents)
+	}
+	if res.StateID == "" {
+		return State{}, fmt.Errorf("no state ID returned")
+	}
+	if res.StateContents == "" {
+		return State{}, fmt.Errorf("no state contents returned")
+	}
+
+	values, err := unpackValues(res.StateContents)
+	if err != nil {
+		return State{}, fmt.Errorf("unable to decode state contents: %s", err.Error())
+	}
+	return State{
+		ID:       res.StateID,
+		Contents: res.StateContents,
+		Values:   values,
+	}, nil
+}
+
+// PriorState returns a state holding exactly values, e.g. a state
+// written by an earlier provider version which lacks newer attributes.
+// The state types are implied by the values.
+func (h *Harness) PriorState(id string, values Values) State {
+	h.t.Helper()
+
+	contents, err := packState(values)
+	if err != nil {
+		h.t.Fatalf("%s prior state: %s", h.typeName, err.Error())
+	}
+	return State{
+		ID:       id,
+		Contents: contents,
+		Values:   values,
+	}
+}
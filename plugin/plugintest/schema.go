@@ -0,0 +1,222 @@
+package plugintest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	// Registers the schema attribute types for decoding schemas.
+	_ "github.com/zipstack/pct-plugin-framework/server"
+)
+
+// Decodes the schema returned by a provider or resource.
+func decodeSchema(res *schema.ServiceResponse) (*schema.Schema, error) {
+	if res.ErrorsContents != "" {
+		return nil, fmt.Errorf("%s", res.ErrorsContents)
+	}
+
+	s := schema.Schema{}
+	err := fwhelpers.Decode(res.SchemaContents, &s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode schema: %s", err.Error())
+	}
+	return &s, nil
+}
+
+// Returns the object type matching the schema attributes for values.
+// Like the engine, unset members of an exactly one of group are left out
+// of the type rather than null, so they decode into omitempty fields.
+func configType(attributes map[string]schema.Attribute, exactlyOneOf []string, values map[string]interface{}) (cty.Type, error) {
+	omitted := map[string]bool{}
+	for _, name := range exactlyOneOf {
+		omitted[name] = values[name] == nil
+	}
+
+	attrTypes := map[string]cty.Type{}
+	for name, a := range attributes {
+		if omitted[name] {
+			continue
+		}
+
+		var ty cty.Type
+		var err error
+		nested, ok := asValues(values[name])
+		if m, isMap := a.(*schema.MapAttribute); isMap && ok {
+			ty, err = configType(m.Attributes, m.ExactlyOneOf, nested)
+		} else {
+			ty, err = attributeType(a)
+		}
+		if err != nil {
+			return cty.NilType, fmt.Errorf("%s: %s", name, err.Error())
+		}
+		attrTypes[name] = ty
+	}
+	return cty.Object(attrTypes), nil
+}
+
+func attributeType(a schema.Attribute) (cty.Type, error) {
+	switch t := a.(type) {
+	case *schema.StringAttribute:
+		return cty.String, nil
+	case *schema.IntAttribute, *schema.FloatAttribute:
+		return cty.Number, nil
+	case *schema.BoolAttribute:
+		return cty.Bool, nil
+	case *schema.MapAttribute:
+		return configType(t.Attributes, nil, nil)
+	case *schema.ListAttribute:
+		if t.NestedAttribute == nil {
+			return cty.NilType, fmt.Errorf("list attribute without nested attribute")
+		}
+		elem, err := attributeType(t.NestedAttribute)
+		if err != nil {
+			return cty.NilType, err
+		}
+		return cty.List(elem), nil
+	default:
+		return cty.NilType, fmt.Errorf("unsupported attribute type %T", a)
+	}
+}
+
+// Checks that config sets all the required attributes and no unknown
+// ones, which would otherwise only fail when decoded by the resource.
+// Of the attributes in exactlyOneOf, only one must be set.
+func checkConfig(attributes map[string]schema.Attribute, exactlyOneOf []string, config map[string]interface{}, prefix string) error {
+	for name := range config {
+		if _, ok := attributes[name]; !ok {
+			return fmt.Errorf("unknown attribute %s%s", prefix, name)
+		}
+	}
+
+	oneOf := map[string]bool{}
+	set := []string{}
+	for _, name := range exactlyOneOf {
+		oneOf[name] = true
+		if config[name] != nil {
+			set = append(set, prefix+name)
+		}
+	}
+	if len(exactlyOneOf) > 0 && len(set) != 1 {
+		return fmt.Errorf(
+			"exactly one of %s%s must be set, got %d",
+			prefix, strings.Join(exactlyOneOf, ", "+prefix), len(set),
+		)
+	}
+
+	for name, a := range attributes {
+		v, ok := config[name]
+		if !ok || v == nil {
+			if a.IsRequired() && !oneOf[name] {
+				return fmt.Errorf("missing required attribute %s%s", prefix, name)
+			}
+			continue
+		}
+
+		m, ok := a.(*schema.MapAttribute)
+		if !ok {
+			continue
+		}
+		nested, ok := asValues(v)
+		if !ok {
+			return fmt.Errorf("attribute %s%s is not an object", prefix, name)
+		}
+		err := checkConfig(m.Attributes, m.ExactlyOneOf, nested, prefix+name+".")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func asValues(v interface{}) (map[string]interface{}, bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t, true
+	case Values:
+		return t, true
+	default:
+		return nil, false
+	}
+}
+
+// Returns the zero value of a type, like "" for strings or an object
+// of zero values for objects.
+func zeroValue(ty cty.Type) interface{} {
+	switch {
+	case ty == cty.String:
+		return ""
+	case ty == cty.Number:
+		return 0
+	case ty == cty.Bool:
+		return false
+	case ty.IsListType():
+		return []interface{}{}
+	case ty.IsObjectType():
+		o := map[string]interface{}{}
+		for name, attrTy := range ty.AttributeTypes() {
+			o[name] = zeroValue(attrTy)
+		}
+		return o
+	default:
+		return nil
+	}
+}
+
+// Encodes values like the engine does for plan and config contents.
+// Attributes without a value are null.
+func packValues(ty cty.Type, values map[string]interface{}) (string, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	val, err := ctyjson.Unmarshal(b, ty)
+	if err != nil {
+		return "", fmt.Errorf("values don't match the schema: %s", err.Error())
+	}
+
+	return fwhelpers.PackModel(&val, nil)
+}
+
+// Decodes packed contents, e.g. from a state, into plain values.
+func unpackValues(contents string) (map[string]interface{}, error) {
+	b, err := base64.StdEncoding.DecodeString(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var packed struct {
+		Value map[string]interface{} `json:"value"`
+	}
+	err = json.Unmarshal(b, &packed)
+	if err != nil {
+		return nil, err
+	}
+	return packed.Value, nil
+}
+
+// Encodes values like a resource encodes its state, with the types
+// implied by the values.
+func packState(values map[string]interface{}) (string, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	ty, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return "", err
+	}
+	val, err := ctyjson.Unmarshal(b, ty)
+	if err != nil {
+		return "", err
+	}
+
+	return fwhelpers.PackModel(&val, nil)
+}
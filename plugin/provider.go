@@ -1,7 +1,11 @@
 package plugin
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"os"
+	"sync"
 
 	"github.com/zipstack/pct-plugin-framework/fwhelpers"
 	"github.com/zipstack/pct-plugin-framework/schema"
@@ -13,6 +17,9 @@ import (
 type Provider struct {
 	Client           *api.Client
 	ResourceServices map[string]string
+
+	mu           sync.Mutex
+	clientHandle string
 }
 
 // Model maps the provider state as per schema.
@@ -20,6 +27,22 @@ type ProviderModel struct {
 	Host     string `pctsdk:"host"`
 	Username string `pctsdk:"username"`
 	Password string `pctsdk:"password"`
+
+	CACertFile         *string `pctsdk:"ca_cert_file"`
+	CACertPEM          *string `pctsdk:"ca_cert_pem"`
+	ClientCertFile     *string `pctsdk:"client_cert_file"`
+	ClientKeyFile      *string `pctsdk:"client_key_file"`
+	ClientCertPEM      *string `pctsdk:"client_cert_pem"`
+	ClientKeyPEM       *string `pctsdk:"client_key_pem"`
+	TLSMinVersion      *string `pctsdk:"tls_min_version"`
+	InsecureSkipVerify *bool   `pctsdk:"insecure_skip_verify"`
+
+	ProxyURL *string `pctsdk:"proxy_url"`
+	LogLevel *string `pctsdk:"log_level"`
+
+	HealthCheck *bool `pctsdk:"health_check"`
+
+	APIBackend *string `pctsdk:"api_backend"`
 }
 
 // Ensure the implementation satisfies the expected interfaces
@@ -27,6 +50,15 @@ var (
 	_ schema.ProviderService = &Provider{}
 )
 
+// Clients configured by the provider, keyed by an opaque handle.
+// Resources run in the same process, so they only receive the handle
+// as resource data and share the client, instead of getting the API
+// credentials and building clients of their own.
+var clients = struct {
+	sync.RWMutex
+	byHandle map[string]*api.Client
+}{byHandle: map[string]*api.Client{}}
+
 // Helper function to return a provider service instance.
 func NewProvider() schema.ProviderService {
 	return &Provider{}
@@ -45,8 +77,9 @@ func (p *Provider) Schema() *schema.ServiceResponse {
 		Description: "Airbyte provider plugin",
 		Attributes: map[string]schema.Attribute{
 			"host": &schema.StringAttribute{
-				Description: "URI for Airbyte API. May also be provided via AIRBYTE_HOST environment variable.",
-				Required:    true,
+				Description: "URI for Airbyte API, or unix:// followed by the path of a Unix domain socket to reach it through. " +
+					"May also be provided via AIRBYTE_HOST environment variable.",
+				Required: true,
 			},
 			"username": &schema.StringAttribute{
 				Description: "Basic auth username for Airbyte API. May also be provided via AIRBYTE_USERNAME environment variable.",
@@ -58,6 +91,67 @@ func (p *Provider) Schema() *schema.ServiceResponse {
 				Required:    true,
 				Sensitive:   true,
 			},
+			"ca_cert_file": &schema.StringAttribute{
+				Description: "Path to a PEM encoded CA bundle to verify the Airbyte API server with.",
+				Optional:    true,
+			},
+			"ca_cert_pem": &schema.StringAttribute{
+				Description: "PEM encoded CA bundle to verify the Airbyte API server with.",
+				Optional:    true,
+			},
+			"client_cert_file": &schema.StringAttribute{
+				Description: "Path to a PEM encoded client certificate for mutual TLS.",
+				Optional:    true,
+			},
+			"client_key_file": &schema.StringAttribute{
+				Description: "Path to the PEM encoded private key of the client certificate.",
+				Optional:    true,
+			},
+			"client_cert_pem": &schema.StringAttribute{
+				Description: "PEM encoded client certificate for mutual TLS.",
+				Optional:    true,
+			},
+			"client_key_pem": &schema.StringAttribute{
+				Description: "PEM encoded private key of the client certificate.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"tls_min_version": &schema.StringAttribute{
+				Description: "Minimum TLS version, one of 1.0, 1.1, 1.2 or 1.3. Defaults to 1.2.",
+				Optional:    true,
+			},
+			"insecure_skip_verify": &schema.BoolAttribute{
+				Description: "Skip verification of the Airbyte API server certificate. Only meant for development.",
+				Optional:    true,
+			},
+			"proxy_url": &schema.StringAttribute{
+				Description: "URL of a http, https or socks5 proxy to reach the Airbyte API through. " +
+					"Hosts listed in the NO_PROXY environment variable are not proxied. " +
+					"When not set, the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"log_level": &schema.StringAttribute{
+				Description: "Verbosity of the Airbyte API request logs, one of off, error, info, debug or trace. " +
+					"Failed requests are logged from error level, all requests with their method, path, status and latency " +
+					"from info level, with request IDs and exception stacks from debug level, and with request and response bodies, " +
+					"secrets redacted, at trace level. " +
+					"May also be provided via AIRBYTE_LOG_LEVEL environment variable. Defaults to off.",
+				Optional: true,
+			},
+			"api_backend": &schema.StringAttribute{
+				Description: "Airbyte API to talk to, either config for the internal config API (/api/v1) " +
+					"or public for the public API (/api/public/v1). The public API has no schema discovery " +
+					"and no connection state, so the source schema and connection state data sources and the " +
+					"connection stream state resource need the config API. Defaults to config.",
+				Optional: true,
+			},
+			"health_check": &schema.BoolAttribute{
+				Description: "Check that the Airbyte API is reachable and accepts the credentials " +
+					"when the provider is configured, to fail early with a diagnosis of the problem. " +
+					"Leave it off when Airbyte is created in the same run. Defaults to false.",
+				Optional: true,
+			},
 		},
 	}
 
@@ -85,27 +179,73 @@ func (p *Provider) Configure(req *schema.ServiceRequest) *schema.ServiceResponse
 		))
 	}
 
-	if p.Client == nil {
-		client, err := api.NewClient(pm.Host, pm.Username, pm.Password)
+	logLevel := stringValue(pm.LogLevel)
+	if logLevel == "" {
+		logLevel = os.Getenv("AIRBYTE_LOG_LEVEL")
+	}
+	level, err := api.ParseLogLevel(logLevel)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// The client is rebuilt on every Configure, so that changes to the
+	// host, credentials or TLS settings are taken into account.
+	config := api.ClientConfig{
+		Host:     pm.Host,
+		Username: pm.Username,
+		Password: pm.Password,
+		TLS: api.TLSConfig{
+			CACertFile:     stringValue(pm.CACertFile),
+			CACertPEM:      stringValue(pm.CACertPEM),
+			ClientCertFile: stringValue(pm.ClientCertFile),
+			ClientKeyFile:  stringValue(pm.ClientKeyFile),
+			ClientCertPEM:  stringValue(pm.ClientCertPEM),
+			ClientKeyPEM:   stringValue(pm.ClientKeyPEM),
+			MinVersion:     stringValue(pm.TLSMinVersion),
+
+			InsecureSkipVerify: boolValue(pm.InsecureSkipVerify),
+		},
+		ProxyURL: stringValue(pm.ProxyURL),
+		LogLevel: level,
+		Backend:  stringValue(pm.APIBackend),
+	}
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	if boolValue(pm.HealthCheck) {
+		err = client.CheckConnectivity()
 		if err != nil {
-			return schema.ErrorResponse(err)
+			return schema.ErrorResponse(fmt.Errorf(
+				"unable to use the Airbyte API at %s", err.Error(),
+			))
 		}
-		p.Client = client
 	}
 
-	// Make API creds available for Resource type Configure methods.
-	creds := map[string]string{
-		"host":     pm.Host,
-		"username": pm.Username,
-		"password": pm.Password,
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The handle stays the same for the provider, so the new client
+	// replaces the previous one instead of adding an entry.
+	if p.clientHandle == "" {
+		p.clientHandle, err = newClientHandle()
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
 	}
-	cEnc, err := fwhelpers.Encode(creds)
+	storeClient(p.clientHandle, client)
+	p.Client = client
+
+	// Make the API client available for Resource type Configure methods.
+	hEnc, err := fwhelpers.Encode(p.clientHandle)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
 	return &schema.ServiceResponse{
-		ResourceData: cEnc,
+		ResourceData: hEnc,
 	}
 }
 
@@ -120,3 +260,40 @@ func (p *Provider) UpdateResourceServices(resServices map[string]string) {
 		p.ResourceServices = resServices
 	}
 }
+
+// Returns a new handle to store a provider client under.
+func newClientHandle() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Stores the client under the handle, replacing the previous one.
+func storeClient(handle string, client *api.Client) {
+	clients.Lock()
+	defer clients.Unlock()
+	clients.byHandle[handle] = client
+}
+
+// Returns the provider configured client for the resource data
+// received by resource Configure methods.
+func configuredClient(resourceData string) (*api.Client, error) {
+	var handle string
+	err := fwhelpers.Decode(resourceData, &handle)
+	if err != nil {
+		return nil, fmt.Errorf("malformed data provided to configure resource")
+	}
+
+	clients.RLock()
+	defer clients.RUnlock()
+
+	client, ok := clients.byHandle[handle]
+	if !ok {
+		return nil, fmt.Errorf("provider is not configured, unable to configure resource")
+	}
+
+	return client, nil
+}
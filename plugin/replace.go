@@ -0,0 +1,64 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Replaces a resource whose immutable attributes changed, which Airbyte
+// can't update in place. The new resource is created before the previous
+// one is deleted, so that a failed create leaves everything as is.
+func replaceResource(r schema.ResourceService, req *schema.ServiceRequest) *schema.ServiceResponse {
+	res := r.Create(&schema.ServiceRequest{
+		TypeName:     req.TypeName,
+		PlanContents: req.PlanContents,
+	})
+	if res.ErrorsContents != "" {
+		return res
+	}
+
+	del := r.Delete(&schema.ServiceRequest{
+		TypeName:      req.TypeName,
+		StateID:       req.PlanID,
+		StateContents: req.StateContents,
+	})
+	if del.ErrorsContents != "" {
+		return schema.ErrorResponse(fmt.Errorf(
+			"replaced %s with %s, but failed to delete it: %s.\n"+
+				"Delete %s manually, and import %s or set adopt_existing to keep the replacement.",
+			req.PlanID, res.StateID, del.ErrorsContents, req.PlanID, res.StateID,
+		))
+	}
+
+	return res
+}
+
+// Replaces a source or destination like replaceResource. Airbyte deletes
+// the connections of a deleted source or destination along with it, so
+// the replacement is refused while connections in the workspace use it.
+func replaceActor(r schema.ResourceService, req *schema.ServiceRequest, client *api.Client, kind string, workspaceId string) *schema.ServiceResponse {
+	connections, err := client.ListWebBackendConnections(workspaceId)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	ids := []string{}
+	for _, c := range connections {
+		if c.Source.SourceId == req.PlanID || c.Destination.DestinationId == req.PlanID {
+			ids = append(ids, c.ConnectionId)
+		}
+	}
+	if len(ids) > 0 {
+		return schema.ErrorResponse(fmt.Errorf(
+			"%s %s must be replaced, but replacing it would delete its connections %s.\n"+
+				"Taint or recreate those connections along with the %s, or delete them first.",
+			kind, req.PlanID, strings.Join(ids, ", "), kind,
+		))
+	}
+
+	return replaceResource(r, req)
+}
@@ -0,0 +1,279 @@
+package plugin_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api/airbytetest"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+// Definition IDs the resources are created with. The fake Airbyte
+// accepts any, but these are the ones of the real connectors.
+const (
+	stripeDefinitionId         = "e094cb9a-26de-4645-8761-65c0c425d1de"
+	hubspotDefinitionId        = "36c891d9-4bd9-43ac-bad2-10e12756272c"
+	shopifyDefinitionId        = "9da77001-af33-4bcd-be46-6252bf9342b9"
+	zendeskSupportDefinitionId = "79c1aa37-dae3-42ae-b333-d1c105477715"
+	pipedriveDefinitionId      = "d8286229-c680-4063-8c59-23b9b391c700"
+	mailchimpDefinitionId      = "b03a9f3e-22a5-11eb-adc1-0242ac120002"
+	amplitudeDefinitionId      = "fa9f58c6-2d03-4237-aaa4-07d75e0c1396"
+	freshdeskDefinitionId      = "ec4b9503-13cb-48ab-a4ab-6ade4be46567"
+	localCSVDefinitionId       = "8be1cf83-fde1-477f-a4ad-318d23c9f3c6"
+)
+
+func newServer(t *testing.T) *airbytetest.Server {
+	t.Helper()
+
+	s := airbytetest.NewServer()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Returns a check that the state values at the paths are as wanted.
+func checkValues(want map[string]string) func(s plugintest.State) error {
+	return func(s plugintest.State) error {
+		for path, v := range want {
+			if got := s.String(path); got != v {
+				return fmt.Errorf("%s = %q, want %q", path, got, v)
+			}
+		}
+		if s.String("secrets_hash") == "" {
+			return fmt.Errorf("secrets_hash is not set")
+		}
+		return nil
+	}
+}
+
+// Source resource test case, with the connection configurations it is
+// created and updated with, and the path of a secret in the state. The
+// secret is configured as "secret", and updated to "rotated".
+type sourceTest struct {
+	resource     func() schema.ResourceService
+	definitionId string
+	create       plugintest.Values
+	update       plugintest.Values
+	secret       string
+}
+
+// Runs the lifecycle of the source, and checks that state written by
+// earlier versions without secrets_hash still works, and that secrets
+// masked by Airbyte don't show up as changes.
+func (st sourceTest) run(t *testing.T) {
+	t.Run("lifecycle", func(t *testing.T) {
+		s := newServer(t)
+
+		plugintest.RunLifecycle(t, plugintest.Lifecycle{
+			ProviderConfig: plugintest.ProviderConfig(s),
+			Resource:       st.resource(),
+			Create: plugintest.Step{
+				Config: st.config(s, "source", st.create),
+				Check:  checkValues(map[string]string{"name": "source", st.secret: "secret"}),
+			},
+			Update: plugintest.Step{
+				Config: st.config(s, "source renamed", st.update),
+				Check:  checkValues(map[string]string{"name": "source renamed", st.secret: "rotated"}),
+			},
+			CheckDestroy: func(state plugintest.State) error {
+				if _, ok := s.Source(state.ID); ok {
+					return fmt.Errorf("source %s still exists", state.ID)
+				}
+				return nil
+			},
+		})
+	})
+
+	t.Run("state without secrets_hash", func(t *testing.T) {
+		s := newServer(t)
+		h := plugintest.New(t, plugintest.ProviderConfig(s), st.resource())
+
+		created := h.Create(st.config(s, "source", st.create))
+		values := plugintest.Values{}
+		for name, v := range created.Values {
+			if name != "secrets_hash" {
+				values[name] = v
+			}
+		}
+		prior := h.PriorState(created.ID, values)
+
+		read := h.Read(prior)
+		if got := read.String(st.secret); got != "secret" {
+			t.Errorf("%s = %q after read, want it kept", st.secret, got)
+		}
+
+		updated := h.Update(prior, st.config(s, "source renamed", st.update))
+		if updated.String("secrets_hash") == "" {
+			t.Error("secrets_hash is not set after update")
+		}
+	})
+
+	t.Run("secret references", func(t *testing.T) {
+		s := newServer(t)
+		h := plugintest.New(t, plugintest.ProviderConfig(s), st.resource())
+
+		path := strings.TrimPrefix(st.secret, "connection_configuration.")
+		file := filepath.Join(t.TempDir(), "secret")
+		err := os.WriteFile(file, []byte("secret\n"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileRef := "file://" + file
+		envRef := "env://PCT_TEST_SECRET"
+		t.Setenv("PCT_TEST_SECRET", "rotated")
+
+		// References are kept in state, and only their values are sent.
+		created := h.Create(st.config(s, "source", withValue(st.create, path, fileRef)))
+		if got := created.String(st.secret); got != fileRef {
+			t.Errorf("%s = %q after create, want the reference", st.secret, got)
+		}
+		if !storedValue(s, created.ID, "secret") {
+			t.Errorf("file reference not resolved on create")
+		}
+		read := h.Read(created)
+		if got := read.String(st.secret); got != fileRef {
+			t.Errorf("%s = %q after read, want the reference", st.secret, got)
+		}
+
+		updated := h.Update(read, st.config(s, "source renamed", withValue(st.update, path, envRef)))
+		if got := updated.String(st.secret); got != envRef {
+			t.Errorf("%s = %q after update, want the reference", st.secret, got)
+		}
+		if !storedValue(s, updated.ID, "rotated") {
+			t.Errorf("env reference not resolved on update")
+		}
+
+		// A new value behind the reference clears the secret on read, so
+		// that the change shows up in the plan.
+		t.Setenv("PCT_TEST_SECRET", "rotated again")
+		read = h.Read(updated)
+		if got := read.String(st.secret); got != "" {
+			t.Errorf("%s = %q after the referenced value changed, want it cleared", st.secret, got)
+		}
+		h.Delete(read)
+	})
+
+	t.Run("masked secrets", func(t *testing.T) {
+		s := newServer(t)
+		s.SetMaskSecrets(true)
+		h := plugintest.New(t, plugintest.ProviderConfig(s), st.resource())
+
+		created := h.Create(st.config(s, "source", st.create))
+		read := h.Read(created)
+		if got := read.String(st.secret); got != "secret" {
+			t.Errorf("%s = %q after read, want the configured secret", st.secret, got)
+		}
+		h.Delete(read)
+	})
+}
+
+// Returns the source configuration with the connection configuration.
+func (st sourceTest) config(s *airbytetest.Server, name string, connConfig plugintest.Values) plugintest.Values {
+	return plugintest.Values{
+		"name":                     name,
+		"source_definition_id":     st.definitionId,
+		"workspace_id":             s.WorkspaceId(),
+		"connection_configuration": connConfig,
+	}
+}
+
+// Returns a copy of the values with the one at the dotted path set to v.
+func withValue(values plugintest.Values, path string, v interface{}) plugintest.Values {
+	c := plugintest.Values{}
+	for name, value := range values {
+		c[name] = value
+	}
+	name, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		c[name] = v
+		return c
+	}
+	inner, _ := c[name].(plugintest.Values)
+	c[name] = withValue(inner, rest, v)
+	return c
+}
+
+// Returns the connection configuration the fake Airbyte stores for the source.
+func storedConfig(s *airbytetest.Server, sourceId string) map[string]interface{} {
+	stored, _ := s.Source(sourceId)
+	config, _ := stored["connectionConfiguration"].(map[string]interface{})
+	return config
+}
+
+// Returns the string at the dotted path of the connection configuration
+// stored for the source, or "" when there is none.
+func storedString(s *airbytetest.Server, sourceId string, path string) string {
+	var v interface{} = storedConfig(s, sourceId)
+	for _, name := range strings.Split(path, ".") {
+		m, _ := v.(map[string]interface{})
+		v = m[name]
+	}
+	str, _ := v.(string)
+	return str
+}
+
+// Reports whether the connection configuration stored for the source
+// holds the string value anywhere.
+func storedValue(s *airbytetest.Server, sourceId string, value string) bool {
+	var contains func(v interface{}) bool
+	contains = func(v interface{}) bool {
+		switch t := v.(type) {
+		case string:
+			return t == value
+		case map[string]interface{}:
+			for _, val := range t {
+				if contains(val) {
+					return true
+				}
+			}
+		case []interface{}:
+			for _, val := range t {
+				if contains(val) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return contains(storedConfig(s, sourceId))
+}
+
+// Checks that state written before schema version 1, where the variants
+// of the block were flat attributes next to the discriminator, is
+// upgraded to the nested block of the variant on read. The block of the
+// created state is replaced with flat, which holds the secret too.
+func (st sourceTest) runUpgrade(t *testing.T, block string, discriminator string, flat plugintest.Values) {
+	t.Run("upgrade flat "+block, func(t *testing.T) {
+		s := newServer(t)
+		h := plugintest.New(t, plugintest.ProviderConfig(s), st.resource())
+
+		created := h.Create(st.config(s, "source", st.create))
+
+		values := plugintest.Values{}
+		for name, v := range created.Values {
+			if name != "secrets_hash" {
+				values[name] = v
+			}
+		}
+		connConfig := plugintest.Values{}
+		for name, v := range created.Values["connection_configuration"].(map[string]interface{}) {
+			connConfig[name] = v
+		}
+		connConfig[block] = flat
+		values["connection_configuration"] = connConfig
+
+		read := h.Read(h.PriorState(created.ID, values))
+		if got := read.String(st.secret); got != "secret" {
+			t.Errorf("%s = %q after read, want the secret of the flat state", st.secret, got)
+		}
+		if _, ok := read.Attr("connection_configuration." + block + "." + discriminator); ok {
+			t.Errorf("flat discriminator still in state after read")
+		}
+
+		h.Update(read, st.config(s, "source renamed", st.update))
+	})
+}
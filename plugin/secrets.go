@@ -0,0 +1,165 @@
+package plugin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Schemes of secret references, which are resolved right before API
+// calls, e.g. file:///run/secrets/stripe_key or env://STRIPE_KEY.
+// Any other value is used as is.
+const (
+	secretFileScheme = "file://"
+	secretEnvScheme  = "env://"
+)
+
+// Returns the value a secret reference points to, or a literal as is.
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretFileScheme):
+		path := strings.TrimPrefix(value, secretFileScheme)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("unable to read secret file %s: %s", path, err.Error())
+		}
+		// Secret files usually end with a newline, which isn't part of the secret.
+		return strings.TrimRight(string(b), "\r\n"), nil
+
+	case strings.HasPrefix(value, secretEnvScheme):
+		name := strings.TrimPrefix(value, secretEnvScheme)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret environment variable %s is not set", name)
+		}
+		return v, nil
+
+	default:
+		return value, nil
+	}
+}
+
+// Replaces the secret references with their values in place,
+// e.g. in an API request body. Unset optional secrets are skipped.
+func resolveSecrets(secrets ...*string) error {
+	for _, s := range secrets {
+		if s == nil {
+			continue
+		}
+		v, err := resolveSecret(*s)
+		if err != nil {
+			return err
+		}
+		*s = v
+	}
+	return nil
+}
+
+// Returns the hash of the resolved secret values, which is stored in
+// state instead of them, so that rotated secrets can be detected. It is
+// a HMAC keyed with a random salt, stored along as "<salt>:<hmac>", so
+// that equal secrets don't hash the same across resources.
+func secretsHash(values ...string) *string {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		// Without a hash rotated secrets are not detected, which
+		// is still better than failing the apply.
+		return nil
+	}
+
+	hash := hex.EncodeToString(salt) + ":" + hex.EncodeToString(secretsHMAC(salt, values))
+	return &hash
+}
+
+func secretsHMAC(salt []byte, values []string) []byte {
+	h := hmac.New(sha256.New, salt)
+	for _, v := range values {
+		fmt.Fprintf(h, "%d:%s;", len(v), v)
+	}
+	return h.Sum(nil)
+}
+
+// Reports whether the hash was made by secretsHash from the values.
+func secretsHashMatches(hash string, values ...string) bool {
+	saltHex, macHex, ok := strings.Cut(hash, ":")
+	if !ok {
+		return false
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return false
+	}
+	mac, err := hex.DecodeString(macHex)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(mac, secretsHMAC(salt, values))
+}
+
+// Clears the configured secrets when the values they resolve to no
+// longer match the hash, so that the change shows up in the plan and
+// the new values get sent on update. Unset optional secrets hash like
+// empty ones and stay unset. Without a hash, e.g. in state written
+// before hashes were stored, nothing is cleared.
+func clearRotatedSecrets(hash *string, secrets ...*string) {
+	if hash == nil || *hash == "" {
+		return
+	}
+
+	values := []string{}
+	for _, s := range secrets {
+		if s == nil {
+			values = append(values, "")
+			continue
+		}
+		v, err := resolveSecret(*s)
+		if err != nil {
+			// Reported by the update which clearing triggers.
+			v = ""
+		}
+		values = append(values, v)
+	}
+
+	if !secretsHashMatches(*hash, values...) {
+		for _, s := range secrets {
+			if s != nil {
+				*s = ""
+			}
+		}
+	}
+}
+
+// Airbyte only returns secrets masked, e.g. as **********, so unlike
+// other attributes they can't be refreshed from the API. Instead the
+// configured values, which may be file:// or env:// references, are kept:
+// secrets and configured point to the secrets of the model read from the
+// API and of the plan or prior state. Both are listed by the same
+// function, so they line up when the same credentials block is set in
+// both. With the hash of the prior state, as on Read, secrets which now
+// resolve to other values are cleared, so that the rotation shows up as
+// a change. On Create and Update the secrets were just hashed, so the
+// hash is nil there.
+func keepSecrets(hash *string, secrets []*string, configured []*string) {
+	if len(secrets) == len(configured) {
+		for i, s := range secrets {
+			if s != nil && configured[i] != nil {
+				*s = *configured[i]
+			}
+		}
+	}
+	clearRotatedSecrets(hash, secrets...)
+}
+
+// Returns the values of secrets, e.g. to hash them.
+func secretValues(secrets []*string) []string {
+	values := []string{}
+	for _, s := range secrets {
+		values = append(values, *s)
+	}
+	return values
+}
@@ -0,0 +1,73 @@
+package plugin
+
+import (
+	"testing"
+)
+
+func TestSecretsHash(t *testing.T) {
+	hash := secretsHash("key", "secret")
+	if hash == nil {
+		t.Fatal("secretsHash() = nil")
+	}
+	if !secretsHashMatches(*hash, "key", "secret") {
+		t.Errorf("secretsHashMatches(%q) = false, want true for the hashed values", *hash)
+	}
+	if secretsHashMatches(*hash, "key", "rotated") {
+		t.Errorf("secretsHashMatches(%q) = true, want false for other values", *hash)
+	}
+	if other := secretsHash("key", "secret"); *other == *hash {
+		t.Errorf("secretsHash() = %q twice, want a salted hash", *hash)
+	}
+}
+
+func TestClearRotatedSecrets(t *testing.T) {
+	t.Setenv("SECRET", "secret")
+	hash := secretsHash("secret")
+
+	tests := []struct {
+		name  string
+		hash  *string
+		value string
+		want  string
+	}{
+		{"unchanged", hash, "env://SECRET", "env://SECRET"},
+		{"rotated", hash, "other", ""},
+		{"no hash", nil, "other", "other"},
+		{"malformed hash", optionalString("c6a8919abe20"), "other", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := tt.value
+			clearRotatedSecrets(tt.hash, &value)
+			if value != tt.want {
+				t.Errorf("clearRotatedSecrets() left %q, want %q", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepSecrets(t *testing.T) {
+	t.Setenv("SECRET", "secret")
+	hash := secretsHash("secret")
+
+	tests := []struct {
+		name       string
+		hash       *string
+		configured []*string
+		want       string
+	}{
+		{"kept", hash, []*string{optionalString("env://SECRET")}, "env://SECRET"},
+		{"rotated", hash, []*string{optionalString("other")}, ""},
+		{"applied", nil, []*string{optionalString("other")}, "other"},
+		{"other block", hash, []*string{optionalString("env://SECRET"), nil}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masked := "**********"
+			keepSecrets(tt.hash, []*string{&masked}, tt.configured)
+			if masked != tt.want {
+				t.Errorf("keepSecrets() left %q, want %q", masked, tt.want)
+			}
+		})
+	}
+}
@@ -21,15 +21,25 @@ type sourceAmplitudeResourceModel struct {
 	SourceDefinitionId      string                         `pctsdk:"source_definition_id"`
 	WorkspaceId             string                         `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourceAmplitudeConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                          `pctsdk:"adopt_existing"`
+	SecretsHash             *string                        `pctsdk:"secrets_hash"`
 }
 
 type sourceAmplitudeConnConfigModel struct {
-	StartDate  string `pctsdk:"start_date"`
-	DataRegion string `pctsdk:"data_region"`
-	SecretKey  string `pctsdk:"secret_key"`
-	ApiKey     string `pctsdk:"api_key"`
+	StartDate                 string  `pctsdk:"start_date"`
+	DataRegion                *string `pctsdk:"data_region"`
+	RequestTimeRange          *int    `pctsdk:"request_time_range"`
+	ActiveUsersGroupByCountry *bool   `pctsdk:"active_users_group_by_country"`
+	SecretKey                 string  `pctsdk:"secret_key"`
+	ApiKey                    string  `pctsdk:"api_key"`
 }
 
+// Data regions of the Amplitude connector spec.
+const (
+	amplitudeDataRegionStandard = "Standard Server"
+	amplitudeDataRegionEU       = "EU Residency Server"
+)
+
 // Ensure the implementation satisfies the expected interfaces.
 var (
 	_ schema.ResourceService = &sourceAmplitudeResource{}
@@ -54,19 +64,11 @@ func (r *sourceAmplitudeResource) Configure(req *schema.ServiceRequest) *schema.
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -87,28 +89,52 @@ func (r *sourceAmplitudeResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
 				Attributes: map[string]schema.Attribute{
 					"data_region": &schema.StringAttribute{
-						Description: "Data region",
-						Required:    true,
+						Description: "Data region of the Amplitude project, " +
+							"either Standard Server or EU Residency Server. Defaults to Standard Server",
+						Optional: true,
+						Computed: true,
+					},
+					"request_time_range": &schema.IntAttribute{
+						Description: "Number of hours of events requested at once, between 1 and 8760. Defaults to 24",
+						Optional:    true,
+						Computed:    true,
+					},
+					"active_users_group_by_country": &schema.BoolAttribute{
+						Description: "Group the active users by country. Defaults to true",
+						Optional:    true,
+						Computed:    true,
 					},
 					"secret_key": &schema.StringAttribute{
-						Description: "Secret Key",
+						Description: "Secret Key. May be a file:// or env:// secret reference",
 						Required:    true,
+						Sensitive:   true,
 					},
 					"api_key": &schema.StringAttribute{
-						Description: "API Key",
+						Description: "API Key. May be a file:// or env:// secret reference",
 						Required:    true,
+						Sensitive:   true,
 					},
 					"start_date": &schema.StringAttribute{
 						Description: "Start Date",
@@ -139,6 +165,10 @@ func (r *sourceAmplitudeResource) Create(req *schema.ServiceRequest) *schema.Ser
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateAmplitudeConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceAmplitude{}
@@ -151,9 +181,34 @@ func (r *sourceAmplitudeResource) Create(req *schema.ServiceRequest) *schema.Ser
 	body.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
 	body.ConnectionConfiguration.SecretKey = plan.ConnectionConfiguration.SecretKey
 	body.ConnectionConfiguration.DataRegion = plan.ConnectionConfiguration.DataRegion
+	body.ConnectionConfiguration.RequestTimeRange = plan.ConnectionConfiguration.RequestTimeRange
+	body.ConnectionConfiguration.ActiveUsersGroupByCountry = plan.ConnectionConfiguration.ActiveUsersGroupByCountry
+
+	// Resolve secret references right before the API call.
+	err = resolveSecrets(&body.ConnectionConfiguration.ApiKey, &body.ConnectionConfiguration.SecretKey)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
 
-	// Create new source
-	source, err := r.Client.CreateAmplitudeSource(body)
+	var source api.SourceAmplitude
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateAmplitudeSource(body)
+	} else {
+		source, err = r.Client.CreateAmplitudeSource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -164,12 +219,17 @@ func (r *sourceAmplitudeResource) Create(req *schema.ServiceRequest) *schema.Ser
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(body.ConnectionConfiguration.ApiKey, body.ConnectionConfiguration.SecretKey)
 
 	state.ConnectionConfiguration = sourceAmplitudeConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
-	state.ConnectionConfiguration.ApiKey = source.ConnectionConfiguration.ApiKey
-	state.ConnectionConfiguration.SecretKey = source.ConnectionConfiguration.SecretKey
+	state.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
+	state.ConnectionConfiguration.SecretKey = plan.ConnectionConfiguration.SecretKey
 	state.ConnectionConfiguration.DataRegion = source.ConnectionConfiguration.DataRegion
+	state.ConnectionConfiguration.RequestTimeRange = source.ConnectionConfiguration.RequestTimeRange
+	state.ConnectionConfiguration.ActiveUsersGroupByCountry = source.ConnectionConfiguration.ActiveUsersGroupByCountry
+	setAmplitudeDefaults(&state.ConnectionConfiguration)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -196,6 +256,8 @@ func (r *sourceAmplitudeResource) Read(req *schema.ServiceRequest) *schema.Servi
 		return schema.ErrorResponse(err)
 	}
 
+	prior := state
+
 	res := schema.ServiceResponse{}
 
 	if req.StateID != "" {
@@ -216,6 +278,14 @@ func (r *sourceAmplitudeResource) Read(req *schema.ServiceRequest) *schema.Servi
 		state.ConnectionConfiguration.ApiKey = source.ConnectionConfiguration.ApiKey
 		state.ConnectionConfiguration.SecretKey = source.ConnectionConfiguration.SecretKey
 		state.ConnectionConfiguration.DataRegion = source.ConnectionConfiguration.DataRegion
+		state.ConnectionConfiguration.RequestTimeRange = source.ConnectionConfiguration.RequestTimeRange
+		state.ConnectionConfiguration.ActiveUsersGroupByCountry = source.ConnectionConfiguration.ActiveUsersGroupByCountry
+		setAmplitudeDefaults(&state.ConnectionConfiguration)
+
+		keepSecrets(state.SecretsHash,
+			[]*string{&state.ConnectionConfiguration.ApiKey, &state.ConnectionConfiguration.SecretKey},
+			[]*string{&prior.ConnectionConfiguration.ApiKey, &prior.ConnectionConfiguration.SecretKey},
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -242,6 +312,20 @@ func (r *sourceAmplitudeResource) Update(req *schema.ServiceRequest) *schema.Ser
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateAmplitudeConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceAmplitude{}
@@ -253,6 +337,14 @@ func (r *sourceAmplitudeResource) Update(req *schema.ServiceRequest) *schema.Ser
 	body.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
 	body.ConnectionConfiguration.SecretKey = plan.ConnectionConfiguration.SecretKey
 	body.ConnectionConfiguration.DataRegion = plan.ConnectionConfiguration.DataRegion
+	body.ConnectionConfiguration.RequestTimeRange = plan.ConnectionConfiguration.RequestTimeRange
+	body.ConnectionConfiguration.ActiveUsersGroupByCountry = plan.ConnectionConfiguration.ActiveUsersGroupByCountry
+
+	// Resolve secret references right before the API call.
+	err = resolveSecrets(&body.ConnectionConfiguration.ApiKey, &body.ConnectionConfiguration.SecretKey)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Update existing source
 	_, err = r.Client.UpdateAmplitudeSource(body)
@@ -272,12 +364,17 @@ func (r *sourceAmplitudeResource) Update(req *schema.ServiceRequest) *schema.Ser
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(body.ConnectionConfiguration.ApiKey, body.ConnectionConfiguration.SecretKey)
 
 	state.ConnectionConfiguration = sourceAmplitudeConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
-	state.ConnectionConfiguration.ApiKey = source.ConnectionConfiguration.ApiKey
-	state.ConnectionConfiguration.SecretKey = source.ConnectionConfiguration.SecretKey
+	state.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
+	state.ConnectionConfiguration.SecretKey = plan.ConnectionConfiguration.SecretKey
 	state.ConnectionConfiguration.DataRegion = source.ConnectionConfiguration.DataRegion
+	state.ConnectionConfiguration.RequestTimeRange = source.ConnectionConfiguration.RequestTimeRange
+	state.ConnectionConfiguration.ActiveUsersGroupByCountry = source.ConnectionConfiguration.ActiveUsersGroupByCountry
+	setAmplitudeDefaults(&state.ConnectionConfiguration)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -302,3 +399,29 @@ func (r *sourceAmplitudeResource) Delete(req *schema.ServiceRequest) *schema.Ser
 
 	return &schema.ServiceResponse{}
 }
+
+// Checks the connection_configuration values the Amplitude connector would reject.
+func validateAmplitudeConnConfig(c sourceAmplitudeConnConfigModel) error {
+	path := "connection_configuration"
+	if c.DataRegion != nil {
+		err := validateOneOf(path+".data_region", *c.DataRegion, amplitudeDataRegionStandard, amplitudeDataRegionEU)
+		if err != nil {
+			return err
+		}
+	}
+	if c.RequestTimeRange != nil {
+		err := validateRange(path+".request_time_range", *c.RequestTimeRange, 1, 8760)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sets the connector defaults of the unset optional attributes, which are
+// left to the connector on create and update, so that they read back.
+func setAmplitudeDefaults(c *sourceAmplitudeConnConfigModel) {
+	c.DataRegion = stringOrDefault(c.DataRegion, amplitudeDataRegionStandard)
+	c.RequestTimeRange = intOrDefault(c.RequestTimeRange, 24)
+	c.ActiveUsersGroupByCountry = boolOrDefault(c.ActiveUsersGroupByCountry, true)
+}
@@ -0,0 +1,103 @@
+package plugin_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceAmplitudeResource(t *testing.T) {
+	sourceTest{
+		resource:     plugin.NewSourceAmplitudeResource,
+		definitionId: amplitudeDefinitionId,
+		create: plugintest.Values{
+			"start_date": "2021-01-25T00:00:00Z",
+			"api_key":    "secret",
+			"secret_key": "secret key",
+		},
+		update: plugintest.Values{
+			"start_date":                    "2021-01-25T00:00:00Z",
+			"api_key":                       "rotated",
+			"secret_key":                    "secret key",
+			"data_region":                   "EU Residency Server",
+			"request_time_range":            12,
+			"active_users_group_by_country": false,
+		},
+		secret: "connection_configuration.api_key",
+	}.run(t)
+}
+
+func TestSourceAmplitudeResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceAmplitudeResource())
+	config := func(settings plugintest.Values) plugintest.Values {
+		connConfig := plugintest.Values{
+			"start_date": "2021-01-25T00:00:00Z",
+			"api_key":    "secret",
+			"secret_key": "secret key",
+		}
+		for name, v := range settings {
+			connConfig[name] = v
+		}
+		return plugintest.Values{
+			"name":                     "amplitude",
+			"source_definition_id":     amplitudeDefinitionId,
+			"workspace_id":             s.WorkspaceId(),
+			"connection_configuration": connConfig,
+		}
+	}
+
+	// Unset optional attributes are left to the connector defaults,
+	// and read back as them.
+	created := h.Create(config(nil))
+	connConfig := storedConfig(s, created.ID)
+	defaults := map[string]string{
+		"data_region":                   "Standard Server",
+		"request_time_range":            "24",
+		"active_users_group_by_country": "true",
+	}
+	for name := range defaults {
+		if v, ok := connConfig[name]; ok {
+			t.Errorf("%s = %v sent, want it unset", name, v)
+		}
+	}
+	read := h.Read(created)
+	for name, want := range defaults {
+		v, _ := read.Attr("connection_configuration." + name)
+		if got := fmt.Sprint(v); got != want {
+			t.Errorf("%s = %s after read, want the connector default %s", name, got, want)
+		}
+	}
+
+	// Both keys are masked by Airbyte, so the configured ones are kept.
+	for path, want := range map[string]string{"api_key": "secret", "secret_key": "secret key"} {
+		if got := read.String("connection_configuration." + path); got != want {
+			t.Errorf("%s = %q after read, want the configured one", path, got)
+		}
+	}
+
+	// Set ones are sent as is, even when false.
+	updated := h.Update(read, config(plugintest.Values{
+		"data_region":                   "EU Residency Server",
+		"request_time_range":            8760,
+		"active_users_group_by_country": false,
+	}))
+	connConfig = storedConfig(s, updated.ID)
+	if connConfig["data_region"] != "EU Residency Server" || connConfig["active_users_group_by_country"] != false {
+		t.Errorf("connection configuration = %v, want the set data_region and active_users_group_by_country", connConfig)
+	}
+
+	for _, settings := range []plugintest.Values{
+		{"data_region": "US Server"},
+		{"request_time_range": 0},
+		{"request_time_range": 8761},
+	} {
+		_, err := h.TryCreate(config(settings))
+		if err == nil {
+			t.Errorf("%v accepted, want an error", settings)
+		}
+	}
+}
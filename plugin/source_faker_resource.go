@@ -54,19 +54,11 @@ package plugin
 // 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 // 	}
 
-// 	var creds map[string]string
-// 	err := fwhelpers.Decode(req.ResourceData, &creds)
+// 	client, err := configuredClient(req.ResourceData)
 // 	if err != nil {
 // 		return schema.ErrorResponse(err)
 // 	}
 
-// 	client, err := api.NewClient(
-// 		creds["host"], creds["username"], creds["password"],
-// 	)
-// 	if err != nil {
-// 		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-// 	}
-
 // 	r.Client = client
 
 // 	return &schema.ServiceResponse{}
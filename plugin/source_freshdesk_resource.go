@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/zipstack/pct-plugin-framework/fwhelpers"
@@ -21,13 +22,16 @@ type sourceFreshdeskResourceModel struct {
 	SourceDefinitionId      string                         `pctsdk:"source_definition_id"`
 	WorkspaceId             string                         `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourceFreshdeskConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                          `pctsdk:"adopt_existing"`
+	SecretsHash             *string                        `pctsdk:"secrets_hash"`
 }
 
 type sourceFreshdeskConnConfigModel struct {
-	Domain            string `pctsdk:"domain"`
-	StartDate         string `pctsdk:"start_date"`
-	ApiKey            string `pctsdk:"api_key"`
-	RequestsPerMinute int    `pctsdk:"requests_per_minute"`
+	Domain               string `pctsdk:"domain"`
+	StartDate            string `pctsdk:"start_date"`
+	ApiKey               string `pctsdk:"api_key"`
+	RequestsPerMinute    *int   `pctsdk:"requests_per_minute"`
+	LookbackWindowInDays *int   `pctsdk:"lookback_window_in_days"`
 }
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -54,19 +58,11 @@ func (r *sourceFreshdeskResource) Configure(req *schema.ServiceRequest) *schema.
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -86,13 +82,23 @@ func (r *sourceFreshdeskResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
@@ -102,18 +108,24 @@ func (r *sourceFreshdeskResource) Schema() *schema.ServiceResponse {
 						Required:    true,
 					},
 					"api_key": &schema.StringAttribute{
-						Description: "api key",
+						Description: "api key. May be a file:// or env:// secret reference",
 						Required:    true,
 						Sensitive:   true,
 					},
 					"domain": &schema.StringAttribute{
-						Description: "Domain",
+						Description: "Domain of the Freshdesk account as a bare host, e.g. mycompany.freshdesk.com",
 						Required:    true,
 						Sensitive:   true,
 					},
 					"requests_per_minute": &schema.IntAttribute{
-						Description: "Request Per Minute",
-						Required:    true,
+						Description: "Maximum number of requests per minute, between 1 and 1000. " +
+							"Defaults to the limit of the Freshdesk plan",
+						Optional: true,
+					},
+					"lookback_window_in_days": &schema.IntAttribute{
+						Description: "Number of days before the last sync to sync again, to catch updated data. Defaults to 14",
+						Optional:    true,
+						Computed:    true,
 					},
 				},
 			},
@@ -140,6 +152,10 @@ func (r *sourceFreshdeskResource) Create(req *schema.ServiceRequest) *schema.Ser
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateFreshdeskConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceFreshdesk{}
@@ -152,8 +168,32 @@ func (r *sourceFreshdeskResource) Create(req *schema.ServiceRequest) *schema.Ser
 	body.ConnectionConfiguration.Domain = plan.ConnectionConfiguration.Domain
 	body.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
 	body.ConnectionConfiguration.RequestsPerMinute = plan.ConnectionConfiguration.RequestsPerMinute
-	// Create new source
-	source, err := r.Client.CreateFreshdeskSource(body)
+	body.ConnectionConfiguration.LookbackWindowInDays = plan.ConnectionConfiguration.LookbackWindowInDays
+	// Resolve secret references right before the API call.
+	err = resolveSecrets(&body.ConnectionConfiguration.ApiKey)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var source api.SourceFreshdesk
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateFreshdeskSource(body)
+	} else {
+		source, err = r.Client.CreateFreshdeskSource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -164,13 +204,17 @@ func (r *sourceFreshdeskResource) Create(req *schema.ServiceRequest) *schema.Ser
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(body.ConnectionConfiguration.ApiKey)
 
 	state.ConnectionConfiguration = sourceFreshdeskConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.Domain = source.ConnectionConfiguration.Domain
-	state.ConnectionConfiguration.ApiKey = source.ConnectionConfiguration.ApiKey
+	state.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
 	state.ConnectionConfiguration.RequestsPerMinute = source.ConnectionConfiguration.RequestsPerMinute
+	state.ConnectionConfiguration.LookbackWindowInDays = source.ConnectionConfiguration.LookbackWindowInDays
+	setFreshdeskDefaults(&state.ConnectionConfiguration)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -197,6 +241,8 @@ func (r *sourceFreshdeskResource) Read(req *schema.ServiceRequest) *schema.Servi
 		return schema.ErrorResponse(err)
 	}
 
+	prior := state
+
 	res := schema.ServiceResponse{}
 
 	if req.StateID != "" {
@@ -219,6 +265,13 @@ func (r *sourceFreshdeskResource) Read(req *schema.ServiceRequest) *schema.Servi
 		state.ConnectionConfiguration.Domain = source.ConnectionConfiguration.Domain
 		state.ConnectionConfiguration.ApiKey = source.ConnectionConfiguration.ApiKey
 		state.ConnectionConfiguration.RequestsPerMinute = source.ConnectionConfiguration.RequestsPerMinute
+		state.ConnectionConfiguration.LookbackWindowInDays = source.ConnectionConfiguration.LookbackWindowInDays
+		setFreshdeskDefaults(&state.ConnectionConfiguration)
+
+		keepSecrets(state.SecretsHash,
+			[]*string{&state.ConnectionConfiguration.ApiKey},
+			[]*string{&prior.ConnectionConfiguration.ApiKey},
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -245,6 +298,20 @@ func (r *sourceFreshdeskResource) Update(req *schema.ServiceRequest) *schema.Ser
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateFreshdeskConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceFreshdesk{}
@@ -258,6 +325,13 @@ func (r *sourceFreshdeskResource) Update(req *schema.ServiceRequest) *schema.Ser
 	body.ConnectionConfiguration.Domain = plan.ConnectionConfiguration.Domain
 	body.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
 	body.ConnectionConfiguration.RequestsPerMinute = plan.ConnectionConfiguration.RequestsPerMinute
+	body.ConnectionConfiguration.LookbackWindowInDays = plan.ConnectionConfiguration.LookbackWindowInDays
+	// Resolve secret references right before the API call.
+	err = resolveSecrets(&body.ConnectionConfiguration.ApiKey)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
 	// Update existing source
 	_, err = r.Client.UpdateFreshdeskSource(body)
 	if err != nil {
@@ -276,14 +350,18 @@ func (r *sourceFreshdeskResource) Update(req *schema.ServiceRequest) *schema.Ser
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(body.ConnectionConfiguration.ApiKey)
 
 	state.ConnectionConfiguration = sourceFreshdeskConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.Domain = source.ConnectionConfiguration.Domain
-	state.ConnectionConfiguration.ApiKey = source.ConnectionConfiguration.ApiKey
+	state.ConnectionConfiguration.ApiKey = plan.ConnectionConfiguration.ApiKey
 	state.ConnectionConfiguration.RequestsPerMinute = source.ConnectionConfiguration.RequestsPerMinute
+	state.ConnectionConfiguration.LookbackWindowInDays = source.ConnectionConfiguration.LookbackWindowInDays
+	setFreshdeskDefaults(&state.ConnectionConfiguration)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -308,3 +386,35 @@ func (r *sourceFreshdeskResource) Delete(req *schema.ServiceRequest) *schema.Ser
 
 	return &schema.ServiceResponse{}
 }
+
+// Checks the connection_configuration values the Freshdesk connector would reject.
+func validateFreshdeskConnConfig(c sourceFreshdeskConnConfigModel) error {
+	path := "connection_configuration"
+	if strings.Contains(c.Domain, "://") {
+		return fmt.Errorf("%s.domain must be a bare host without a scheme, got %q", path, c.Domain)
+	}
+	err := validatePattern(path+".domain", c.Domain, `^[A-Za-z0-9][A-Za-z0-9.-]*$`)
+	if err != nil {
+		return err
+	}
+	if c.RequestsPerMinute != nil {
+		err = validateRange(path+".requests_per_minute", *c.RequestsPerMinute, 1, 1000)
+		if err != nil {
+			return err
+		}
+	}
+	if c.LookbackWindowInDays != nil {
+		err = validateAtLeast(path+".lookback_window_in_days", *c.LookbackWindowInDays, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sets the connector defaults of the unset optional attributes, which are
+// left to the connector on create and update, so that they read back.
+// The rate limit depends on the Freshdesk plan, so it stays unset.
+func setFreshdeskDefaults(c *sourceFreshdeskConnConfigModel) {
+	c.LookbackWindowInDays = intOrDefault(c.LookbackWindowInDays, 14)
+}
@@ -0,0 +1,96 @@
+package plugin_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceFreshdeskResource(t *testing.T) {
+	sourceTest{
+		resource:     plugin.NewSourceFreshdeskResource,
+		definitionId: freshdeskDefinitionId,
+		create: plugintest.Values{
+			"domain":     "mycompany.freshdesk.com",
+			"start_date": "2020-12-01T00:00:00Z",
+			"api_key":    "secret",
+		},
+		update: plugintest.Values{
+			"domain":                  "mycompany.freshdesk.com",
+			"start_date":              "2020-12-01T00:00:00Z",
+			"api_key":                 "rotated",
+			"requests_per_minute":     100,
+			"lookback_window_in_days": 7,
+		},
+		secret: "connection_configuration.api_key",
+	}.run(t)
+}
+
+func TestSourceFreshdeskResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceFreshdeskResource())
+	config := func(settings plugintest.Values) plugintest.Values {
+		connConfig := plugintest.Values{
+			"domain":     "mycompany.freshdesk.com",
+			"start_date": "2020-12-01T00:00:00Z",
+			"api_key":    "secret",
+		}
+		for name, v := range settings {
+			connConfig[name] = v
+		}
+		return plugintest.Values{
+			"name":                     "freshdesk",
+			"source_definition_id":     freshdeskDefinitionId,
+			"workspace_id":             s.WorkspaceId(),
+			"connection_configuration": connConfig,
+		}
+	}
+
+	// Unset optional attributes are left to the connector defaults. The
+	// lookback window reads back as its default, and the rate limit,
+	// which depends on the Freshdesk plan, stays unset.
+	created := h.Create(config(nil))
+	connConfig := storedConfig(s, created.ID)
+	for _, name := range []string{"requests_per_minute", "lookback_window_in_days"} {
+		if v, ok := connConfig[name]; ok {
+			t.Errorf("%s = %v sent, want it unset", name, v)
+		}
+	}
+	read := h.Read(created)
+	if v, _ := read.Attr("connection_configuration.lookback_window_in_days"); fmt.Sprint(v) != "14" {
+		t.Errorf("lookback_window_in_days = %v after read, want the connector default 14", v)
+	}
+	if v, ok := read.Attr("connection_configuration.requests_per_minute"); ok && v != nil {
+		t.Errorf("requests_per_minute = %v after read, want it unset", v)
+	}
+
+	// The API key is masked by Airbyte, so the configured one is kept.
+	if got := read.String("connection_configuration.api_key"); got != "secret" {
+		t.Errorf("api_key = %q after read, want the configured one", got)
+	}
+
+	// Both ends of the rate limit range are accepted.
+	for _, requestsPerMinute := range []int{1, 1000} {
+		updated := h.Update(read, config(plugintest.Values{"requests_per_minute": requestsPerMinute}))
+		if v, _ := updated.Attr("connection_configuration.requests_per_minute"); fmt.Sprint(v) != fmt.Sprint(requestsPerMinute) {
+			t.Errorf("requests_per_minute = %v, want %d", v, requestsPerMinute)
+		}
+		read = updated
+	}
+
+	for _, settings := range []plugintest.Values{
+		{"domain": "https://mycompany.freshdesk.com"},
+		{"domain": "mycompany.freshdesk.com/"},
+		{"requests_per_minute": 0},
+		{"requests_per_minute": 1001},
+		{"lookback_window_in_days": -1},
+	} {
+		_, err := h.TryCreate(config(settings))
+		if err == nil {
+			t.Errorf("%v accepted, want an error", settings)
+		}
+	}
+}
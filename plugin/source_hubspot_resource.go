@@ -21,19 +21,75 @@ type sourceHubspotResourceModel struct {
 	SourceDefinitionId      string                       `pctsdk:"source_definition_id"`
 	WorkspaceId             string                       `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourceHubspotConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                        `pctsdk:"adopt_existing"`
+	SecretsHash             *string                      `pctsdk:"secrets_hash"`
 }
 
 type sourceHubspotConnConfigModel struct {
-	StartDate   string                 `pctsdk:"start_date"`
-	Credentials HubspotCredConfigModel `pctsdk:"credentials"`
+	StartDate   string                        `pctsdk:"start_date"`
+	Credentials sourceHubspotCredentialsModel `pctsdk:"credentials"`
 }
 
-type HubspotCredConfigModel struct {
-	CredentialsTitle string `pctsdk:"credentials_title"`
-	RefreshToken     string `pctsdk:"refresh_token"`
-	AccessToken      string `pctsdk:"access_token"`
-	ClientSecret     string `pctsdk:"client_secret"`
-	ClientId         string `pctsdk:"client_id"`
+type sourceHubspotCredentialsModel struct {
+	OAuth      *sourceHubspotOAuthModel      `pctsdk:"oauth,omitempty"`
+	PrivateApp *sourceHubspotPrivateAppModel `pctsdk:"private_app,omitempty"`
+}
+
+type sourceHubspotOAuthModel struct {
+	ClientId     string `pctsdk:"client_id"`
+	ClientSecret string `pctsdk:"client_secret"`
+	RefreshToken string `pctsdk:"refresh_token"`
+}
+
+type sourceHubspotPrivateAppModel struct {
+	AccessToken string `pctsdk:"access_token"`
+}
+
+// Hubspot credentials titles, which tell the credentials apart.
+const (
+	hubspotCredentialsOAuth      = "OAuth Credentials"
+	hubspotCredentialsPrivateApp = "Private App Credentials"
+)
+
+// Credentials blocks, one per Hubspot auth method.
+var sourceHubspotCredentials = oneOf{
+	Path:          "connection_configuration.credentials",
+	Discriminator: "credentials_title",
+	Variants: []oneOfVariant{
+		{
+			Key:         "oauth",
+			Value:       hubspotCredentialsOAuth,
+			Description: "Authenticate via OAuth",
+			Attributes: map[string]schema.Attribute{
+				"client_id": &schema.StringAttribute{
+					Description: "Client ID",
+					Required:    true,
+				},
+				"client_secret": &schema.StringAttribute{
+					Description: "Client Secret. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+				"refresh_token": &schema.StringAttribute{
+					Description: "Refresh Token. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+		{
+			Key:         "private_app",
+			Value:       hubspotCredentialsPrivateApp,
+			Description: "Authenticate via a private app access token",
+			Attributes: map[string]schema.Attribute{
+				"access_token": &schema.StringAttribute{
+					Description: "Access Token. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+	},
 }
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -60,19 +116,11 @@ func (r *sourceHubspotResource) Configure(req *schema.ServiceRequest) *schema.Se
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -82,6 +130,8 @@ func (r *sourceHubspotResource) Configure(req *schema.ServiceRequest) *schema.Se
 func (r *sourceHubspotResource) Schema() *schema.ServiceResponse {
 	s := &schema.Schema{
 		Description: "Source Hubspot resource for Airbyte",
+		// Version 1 nests the credentials variants in blocks.
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
 			"name": &schema.StringAttribute{
 				Description: "Name",
@@ -93,13 +143,23 @@ func (r *sourceHubspotResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
@@ -108,40 +168,7 @@ func (r *sourceHubspotResource) Schema() *schema.ServiceResponse {
 						Description: "Start Date",
 						Required:    true,
 					},
-					"credentials": &schema.MapAttribute{
-						Description: "credentials",
-						Required:    true,
-
-						Attributes: map[string]schema.Attribute{
-							"credentials_title": &schema.StringAttribute{
-								Description: "credentials",
-								Required:    true,
-							},
-							"access_token": &schema.StringAttribute{
-								Description: "Access Token",
-								Sensitive:   true,
-								Required:    true,
-								Optional:    true,
-							},
-							"refresh_token": &schema.StringAttribute{
-								Description: "Refresh Token",
-								Sensitive:   true,
-								Required:    true,
-								Optional:    true,
-							},
-							"client_secret": &schema.StringAttribute{
-								Description: "Client Secret",
-								Sensitive:   true,
-								Required:    true,
-								Optional:    true,
-							},
-							"client_id": &schema.StringAttribute{
-								Description: "Client ID",
-								Required:    true,
-								Optional:    true,
-							},
-						},
-					},
+					"credentials": sourceHubspotCredentials.attribute("Credentials, either oauth or private_app", true),
 				},
 			},
 		},
@@ -177,14 +204,36 @@ func (r *sourceHubspotResource) Create(req *schema.ServiceRequest) *schema.Servi
 	body.ConnectionConfiguration = api.SourceHubspotConnConfig{}
 	body.ConnectionConfiguration.StartDate = plan.ConnectionConfiguration.StartDate
 
-	body.ConnectionConfiguration.Credentials = api.HubspotCredConfigModel{}
-	body.ConnectionConfiguration.Credentials.CredentialsTitle = plan.ConnectionConfiguration.Credentials.CredentialsTitle
-	body.ConnectionConfiguration.Credentials.RefreshToken = plan.ConnectionConfiguration.Credentials.RefreshToken
-	body.ConnectionConfiguration.Credentials.ClientSecret = plan.ConnectionConfiguration.Credentials.ClientSecret
-	body.ConnectionConfiguration.Credentials.ClientId = plan.ConnectionConfiguration.Credentials.ClientId
-	body.ConnectionConfiguration.Credentials.AccessToken = plan.ConnectionConfiguration.Credentials.AccessToken
-	// Create new source
-	source, err := r.Client.CreateHubspotSource(body)
+	body.ConnectionConfiguration.Credentials, err = sourceHubspotCredentialsToAPI(plan.ConnectionConfiguration.Credentials)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourceHubspotCredentialsAPISecrets(&body.ConnectionConfiguration.Credentials)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var source api.SourceHubspot
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateHubspotSource(body)
+	} else {
+		source, err = r.Client.CreateHubspotSource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -195,16 +244,17 @@ func (r *sourceHubspotResource) Create(req *schema.ServiceRequest) *schema.Servi
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourceHubspotConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 
-	state.ConnectionConfiguration.Credentials = HubspotCredConfigModel{}
-	state.ConnectionConfiguration.Credentials.CredentialsTitle = source.ConnectionConfiguration.Credentials.CredentialsTitle
-	state.ConnectionConfiguration.Credentials.RefreshToken = source.ConnectionConfiguration.Credentials.RefreshToken
-	state.ConnectionConfiguration.Credentials.ClientSecret = source.ConnectionConfiguration.Credentials.ClientSecret
-	state.ConnectionConfiguration.Credentials.ClientId = source.ConnectionConfiguration.Credentials.ClientId
-	state.ConnectionConfiguration.Credentials.AccessToken = source.ConnectionConfiguration.Credentials.AccessToken
+	state.ConnectionConfiguration.Credentials = sourceHubspotCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+	keepSecrets(nil,
+		sourceHubspotCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+		sourceHubspotCredentialsSecrets(&plan.ConnectionConfiguration.Credentials),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -225,12 +275,18 @@ func (r *sourceHubspotResource) Read(req *schema.ServiceRequest) *schema.Service
 
 	var state sourceHubspotResourceModel
 
-	// Get current state
-	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	// Get current state, upgraded from earlier schema versions.
+	contents, err := sourceHubspotCredentials.upgradeState(req.StateContents)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = fwhelpers.UnpackModel(contents, &state)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
+	prior := state
+
 	res := schema.ServiceResponse{}
 
 	if req.StateID != "" {
@@ -249,12 +305,11 @@ func (r *sourceHubspotResource) Read(req *schema.ServiceRequest) *schema.Service
 		state.ConnectionConfiguration = sourceHubspotConnConfigModel{}
 		state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 
-		state.ConnectionConfiguration.Credentials = HubspotCredConfigModel{}
-		state.ConnectionConfiguration.Credentials.CredentialsTitle = source.ConnectionConfiguration.Credentials.CredentialsTitle
-		state.ConnectionConfiguration.Credentials.RefreshToken = source.ConnectionConfiguration.Credentials.RefreshToken
-		state.ConnectionConfiguration.Credentials.ClientSecret = source.ConnectionConfiguration.Credentials.ClientSecret
-		state.ConnectionConfiguration.Credentials.ClientId = source.ConnectionConfiguration.Credentials.ClientId
-		state.ConnectionConfiguration.Credentials.AccessToken = source.ConnectionConfiguration.Credentials.AccessToken
+		state.ConnectionConfiguration.Credentials = sourceHubspotCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+		keepSecrets(state.SecretsHash,
+			sourceHubspotCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+			sourceHubspotCredentialsSecrets(&prior.ConnectionConfiguration.Credentials),
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -282,6 +337,16 @@ func (r *sourceHubspotResource) Update(req *schema.ServiceRequest) *schema.Servi
 		return schema.ErrorResponse(err)
 	}
 
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
+
 	// Generate API request body from plan
 	body := api.SourceHubspot{}
 	body.Name = plan.Name
@@ -290,12 +355,17 @@ func (r *sourceHubspotResource) Update(req *schema.ServiceRequest) *schema.Servi
 	body.ConnectionConfiguration = api.SourceHubspotConnConfig{}
 	body.ConnectionConfiguration.StartDate = plan.ConnectionConfiguration.StartDate
 
-	body.ConnectionConfiguration.Credentials = api.HubspotCredConfigModel{}
-	body.ConnectionConfiguration.Credentials.CredentialsTitle = plan.ConnectionConfiguration.Credentials.CredentialsTitle
-	body.ConnectionConfiguration.Credentials.RefreshToken = plan.ConnectionConfiguration.Credentials.RefreshToken
-	body.ConnectionConfiguration.Credentials.ClientSecret = plan.ConnectionConfiguration.Credentials.ClientSecret
-	body.ConnectionConfiguration.Credentials.ClientId = plan.ConnectionConfiguration.Credentials.ClientId
-	body.ConnectionConfiguration.Credentials.AccessToken = plan.ConnectionConfiguration.Credentials.AccessToken
+	body.ConnectionConfiguration.Credentials, err = sourceHubspotCredentialsToAPI(plan.ConnectionConfiguration.Credentials)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourceHubspotCredentialsAPISecrets(&body.ConnectionConfiguration.Credentials)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
 	// Update existing source
 	_, err = r.Client.UpdateHubspotSource(body)
 	if err != nil {
@@ -314,16 +384,17 @@ func (r *sourceHubspotResource) Update(req *schema.ServiceRequest) *schema.Servi
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourceHubspotConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 
-	state.ConnectionConfiguration.Credentials = HubspotCredConfigModel{}
-	state.ConnectionConfiguration.Credentials.CredentialsTitle = source.ConnectionConfiguration.Credentials.CredentialsTitle
-	state.ConnectionConfiguration.Credentials.RefreshToken = source.ConnectionConfiguration.Credentials.RefreshToken
-	state.ConnectionConfiguration.Credentials.ClientSecret = source.ConnectionConfiguration.Credentials.ClientSecret
-	state.ConnectionConfiguration.Credentials.ClientId = source.ConnectionConfiguration.Credentials.ClientId
-	state.ConnectionConfiguration.Credentials.AccessToken = source.ConnectionConfiguration.Credentials.AccessToken
+	state.ConnectionConfiguration.Credentials = sourceHubspotCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+	keepSecrets(nil,
+		sourceHubspotCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+		sourceHubspotCredentialsSecrets(&plan.ConnectionConfiguration.Credentials),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -348,3 +419,68 @@ func (r *sourceHubspotResource) Delete(req *schema.ServiceRequest) *schema.Servi
 
 	return &schema.ServiceResponse{}
 }
+
+// Returns the API credentials of the credentials model, with
+// credentials_title set to the value of the configured block.
+func sourceHubspotCredentialsToAPI(m sourceHubspotCredentialsModel) (api.HubspotCredConfigModel, error) {
+	c := api.HubspotCredConfigModel{}
+	err := sourceHubspotCredentials.validate(m.OAuth != nil, m.PrivateApp != nil)
+	if err != nil {
+		return c, err
+	}
+
+	if m.OAuth != nil {
+		c.CredentialsTitle = hubspotCredentialsOAuth
+		c.ClientId = m.OAuth.ClientId
+		c.ClientSecret = m.OAuth.ClientSecret
+		c.RefreshToken = m.OAuth.RefreshToken
+	}
+	if m.PrivateApp != nil {
+		c.CredentialsTitle = hubspotCredentialsPrivateApp
+		c.AccessToken = m.PrivateApp.AccessToken
+	}
+	return c, nil
+}
+
+// Returns the credentials model of the API credentials, with the block
+// matching credentials_title set. Secrets are left as Airbyte returns them.
+func sourceHubspotCredentialsFromAPI(c api.HubspotCredConfigModel) sourceHubspotCredentialsModel {
+	m := sourceHubspotCredentialsModel{}
+	switch c.CredentialsTitle {
+	case hubspotCredentialsOAuth:
+		v := sourceHubspotOAuthModel{}
+		v.ClientId = c.ClientId
+		v.ClientSecret = c.ClientSecret
+		v.RefreshToken = c.RefreshToken
+		m.OAuth = &v
+	case hubspotCredentialsPrivateApp:
+		v := sourceHubspotPrivateAppModel{}
+		v.AccessToken = c.AccessToken
+		m.PrivateApp = &v
+	}
+	return m
+}
+
+// Returns the secrets set in the credentials model, in a stable order.
+func sourceHubspotCredentialsSecrets(c *sourceHubspotCredentialsModel) []*string {
+	secrets := []*string{}
+	if c.OAuth != nil {
+		secrets = append(secrets, &c.OAuth.ClientSecret, &c.OAuth.RefreshToken)
+	}
+	if c.PrivateApp != nil {
+		secrets = append(secrets, &c.PrivateApp.AccessToken)
+	}
+	return secrets
+}
+
+// Returns the secrets set in the credentials API body, in a stable order.
+func sourceHubspotCredentialsAPISecrets(c *api.HubspotCredConfigModel) []*string {
+	secrets := []*string{}
+	switch c.CredentialsTitle {
+	case hubspotCredentialsOAuth:
+		secrets = append(secrets, &c.ClientSecret, &c.RefreshToken)
+	case hubspotCredentialsPrivateApp:
+		secrets = append(secrets, &c.AccessToken)
+	}
+	return secrets
+}
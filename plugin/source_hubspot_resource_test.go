@@ -0,0 +1,116 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceHubspotResource(t *testing.T) {
+	t.Run("oauth", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourceHubspotResource,
+			definitionId: hubspotDefinitionId,
+			create: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{
+						"client_id":     "client",
+						"client_secret": "secret",
+						"refresh_token": "refresh",
+					},
+				},
+			},
+			update: plugintest.Values{
+				"start_date": "2021-01-01T00:00:00Z",
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{
+						"client_id":     "client",
+						"client_secret": "rotated",
+						"refresh_token": "refresh",
+					},
+				},
+			},
+			secret: "connection_configuration.credentials.oauth.client_secret",
+		}
+		st.run(t)
+		st.runUpgrade(t, "credentials", "credentials_title", plugintest.Values{
+			"credentials_title": "OAuth Credentials",
+			"client_id":         "client",
+			"client_secret":     "secret",
+			"refresh_token":     "refresh",
+			"access_token":      "",
+		})
+	})
+
+	t.Run("private app", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourceHubspotResource,
+			definitionId: hubspotDefinitionId,
+			create: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"credentials": plugintest.Values{
+					"private_app": plugintest.Values{"access_token": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"credentials": plugintest.Values{
+					"private_app": plugintest.Values{"access_token": "rotated"},
+				},
+			},
+			secret: "connection_configuration.credentials.private_app.access_token",
+		}
+		st.run(t)
+		st.runUpgrade(t, "credentials", "credentials_title", plugintest.Values{
+			"credentials_title": "Private App Credentials",
+			"client_id":         "",
+			"client_secret":     "",
+			"refresh_token":     "",
+			"access_token":      "secret",
+		})
+	})
+}
+
+func TestSourceHubspotResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceHubspotResource())
+	config := func(credentials plugintest.Values) plugintest.Values {
+		return plugintest.Values{
+			"name":                 "hubspot",
+			"source_definition_id": hubspotDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"start_date":  "2020-01-01T00:00:00Z",
+				"credentials": credentials,
+			},
+		}
+	}
+
+	// The block is sent with the credentials title the connector tells
+	// the variants apart by.
+	created := h.Create(config(plugintest.Values{
+		"oauth": plugintest.Values{"client_id": "client", "client_secret": "secret", "refresh_token": "refresh"},
+	}))
+	if got := storedString(s, created.ID, "credentials.credentials_title"); got != "OAuth Credentials" {
+		t.Errorf("credentials_title = %q sent, want OAuth Credentials", got)
+	}
+
+	// HubSpot doesn't mark the client ID as secret, so it is read back
+	// from Airbyte unmasked.
+	read := h.Read(created)
+	for path, want := range map[string]string{"client_id": "client", "client_secret": "secret", "refresh_token": "refresh"} {
+		if got := read.String("connection_configuration.credentials.oauth." + path); got != want {
+			t.Errorf("%s = %q after read, want %q", path, got, want)
+		}
+	}
+
+	updated := h.Update(read, config(plugintest.Values{
+		"private_app": plugintest.Values{"access_token": "token"},
+	}))
+	if got := storedString(s, updated.ID, "credentials.credentials_title"); got != "Private App Credentials" {
+		t.Errorf("credentials_title = %q sent, want Private App Credentials", got)
+	}
+}
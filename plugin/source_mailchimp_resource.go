@@ -0,0 +1,527 @@
+// Code generated by connectorgen from specs/source_mailchimp.json. DO NOT EDIT.
+
+package plugin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Resource implementation.
+type sourceMailchimpResource struct {
+	Client *api.Client
+}
+
+type sourceMailchimpResourceModel struct {
+	Name                    string                         `pctsdk:"name"`
+	SourceId                string                         `pctsdk:"source_id"`
+	SourceDefinitionId      string                         `pctsdk:"source_definition_id"`
+	WorkspaceId             string                         `pctsdk:"workspace_id"`
+	ConnectionConfiguration sourceMailchimpConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                          `pctsdk:"adopt_existing"`
+	SecretsHash             *string                        `pctsdk:"secrets_hash"`
+}
+
+type sourceMailchimpConnConfigModel struct {
+	StartDate   *string                          `pctsdk:"start_date"`
+	Credentials *sourceMailchimpCredentialsModel `pctsdk:"credentials"`
+}
+
+type sourceMailchimpCredentialsModel struct {
+	Oauth20 *sourceMailchimpCredentialsOauth20Model `pctsdk:"oauth2_0,omitempty"`
+	Apikey  *sourceMailchimpCredentialsApikeyModel  `pctsdk:"apikey,omitempty"`
+}
+
+type sourceMailchimpCredentialsOauth20Model struct {
+	AccessToken  string  `pctsdk:"access_token"`
+	ClientId     *string `pctsdk:"client_id"`
+	ClientSecret *string `pctsdk:"client_secret"`
+}
+
+type sourceMailchimpCredentialsApikeyModel struct {
+	Apikey string `pctsdk:"apikey"`
+}
+
+// Blocks of credentials, told apart by auth_type.
+var sourceMailchimpCredentials = oneOf{
+	Path:          "connection_configuration.credentials",
+	Discriminator: "auth_type",
+	Variants: []oneOfVariant{
+		{
+			Key:   "oauth2_0",
+			Value: "oauth2.0",
+			Attributes: map[string]schema.Attribute{
+				"access_token": &schema.StringAttribute{
+					Description: "An access token generated using the above client ID and secret. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+				"client_id": &schema.StringAttribute{
+					Description: "The Client ID of your OAuth application. May be a file:// or env:// secret reference",
+					Optional:    true,
+					Sensitive:   true,
+				},
+				"client_secret": &schema.StringAttribute{
+					Description: "The Client Secret of your OAuth application. May be a file:// or env:// secret reference",
+					Optional:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+		{
+			Key:   "apikey",
+			Value: "apikey",
+			Attributes: map[string]schema.Attribute{
+				"apikey": &schema.StringAttribute{
+					Description: "Mailchimp API Key. See the docs for information on how to generate this key. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+	},
+}
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ schema.ResourceService = &sourceMailchimpResource{}
+)
+
+// Helper function to return a resource service instance.
+func NewSourceMailchimpResource() schema.ResourceService {
+	return &sourceMailchimpResource{}
+}
+
+// Metadata returns the resource type name.
+// It is always provider name + "_" + resource type name.
+func (r *sourceMailchimpResource) Metadata(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{
+		TypeName: req.TypeName + "_source_mailchimp",
+	}
+}
+
+// Configure adds the provider configured client to the resource.
+func (r *sourceMailchimpResource) Configure(req *schema.ServiceRequest) *schema.ServiceResponse {
+	if req.ResourceData == "" {
+		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
+	}
+
+	client, err := configuredClient(req.ResourceData)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	r.Client = client
+
+	return &schema.ServiceResponse{}
+}
+
+// Schema defines the schema for the resource.
+func (r *sourceMailchimpResource) Schema() *schema.ServiceResponse {
+	s := &schema.Schema{
+		Description: "Source Mailchimp resource for Airbyte",
+		Attributes: map[string]schema.Attribute{
+			"name": &schema.StringAttribute{
+				Description: "Name",
+				Required:    true,
+			},
+			"source_id": &schema.StringAttribute{
+				Description: "Source ID",
+				Computed:    true,
+			},
+			"source_definition_id": &schema.StringAttribute{
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
+				Required:    true,
+			},
+			"workspace_id": &schema.StringAttribute{
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
+				Required:    true,
+			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
+			"connection_configuration": &schema.MapAttribute{
+				Description: "Connection configuration",
+				Required:    true,
+				Attributes: map[string]schema.Attribute{
+					"start_date": &schema.StringAttribute{
+						Description: "The date from which you want to start syncing data for Incremental streams. Only records that have been created or modified since this date will be synced. If left blank, all data will by synced",
+						Optional:    true,
+					},
+					"credentials": sourceMailchimpCredentials.attribute("Authentication", false),
+				},
+			},
+		},
+	}
+
+	sEnc, err := fwhelpers.Encode(s)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		SchemaContents: sEnc,
+	}
+}
+
+// Create a new resource
+func (r *sourceMailchimpResource) Create(req *schema.ServiceRequest) *schema.ServiceResponse {
+	// Retrieve values from plan
+	var plan sourceMailchimpResourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = sourceMailchimpConnConfigValidate(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Generate API request body from plan
+	body := api.SourceMailchimp{}
+	body.Name = plan.Name
+	body.SourceDefinitionId = plan.SourceDefinitionId
+	body.WorkspaceId = plan.WorkspaceId
+	body.ConnectionConfiguration = sourceMailchimpConnConfigToAPI(plan.ConnectionConfiguration)
+	// Resolve secret references right before the API call.
+	secrets := sourceMailchimpConnConfigAPISecrets(&body.ConnectionConfiguration)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var source api.SourceMailchimp
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateMailchimpSource(body)
+	} else {
+		source, err = r.Client.CreateMailchimpSource(body)
+	}
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Update resource state with response body
+	state := sourceMailchimpResourceModel{}
+	state.Name = source.Name
+	state.SourceDefinitionId = source.SourceDefinitionId
+	state.SourceId = source.SourceId
+	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
+	state.ConnectionConfiguration = sourceMailchimpConnConfigFromAPI(source.ConnectionConfiguration)
+	keepSecrets(nil,
+		sourceMailchimpConnConfigSecrets(&state.ConnectionConfiguration),
+		sourceMailchimpConnConfigSecrets(&plan.ConnectionConfiguration),
+	)
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.SourceId,
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Read resource information
+func (r *sourceMailchimpResource) Read(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var state sourceMailchimpResourceModel
+
+	// Get current state
+	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	prior := state
+
+	res := schema.ServiceResponse{}
+
+	if req.StateID != "" {
+		// Query using existing previous state.
+		source, err := r.Client.ReadMailchimpSource(req.StateID)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+
+		// Update state with refreshed value
+		state.Name = source.Name
+		state.SourceDefinitionId = source.SourceDefinitionId
+		state.SourceId = source.SourceId
+		state.WorkspaceId = source.WorkspaceId
+		state.ConnectionConfiguration = sourceMailchimpConnConfigFromAPI(source.ConnectionConfiguration)
+		keepSecrets(state.SecretsHash,
+			sourceMailchimpConnConfigSecrets(&state.ConnectionConfiguration),
+			sourceMailchimpConnConfigSecrets(&prior.ConnectionConfiguration),
+		)
+
+		res.StateID = state.SourceId
+	} else {
+		// No previous state exists.
+		res.StateID = ""
+	}
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	res.StateContents = stateEnc
+
+	return &res
+}
+
+func (r *sourceMailchimpResource) Update(req *schema.ServiceRequest) *schema.ServiceResponse {
+	// Retrieve values from plan
+	var plan sourceMailchimpResourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = sourceMailchimpConnConfigValidate(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
+
+	// Generate API request body from plan
+	body := api.SourceMailchimp{}
+	body.Name = plan.Name
+	body.SourceId = plan.SourceId
+	body.ConnectionConfiguration = sourceMailchimpConnConfigToAPI(plan.ConnectionConfiguration)
+	// Resolve secret references right before the API call.
+	secrets := sourceMailchimpConnConfigAPISecrets(&body.ConnectionConfiguration)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Update existing source
+	_, err = r.Client.UpdateMailchimpSource(body)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Fetch updated items
+	source, err := r.Client.ReadMailchimpSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Update state with refreshed value
+	state := sourceMailchimpResourceModel{}
+	state.Name = source.Name
+	state.SourceDefinitionId = source.SourceDefinitionId
+	state.SourceId = source.SourceId
+	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
+	state.ConnectionConfiguration = sourceMailchimpConnConfigFromAPI(source.ConnectionConfiguration)
+	keepSecrets(nil,
+		sourceMailchimpConnConfigSecrets(&state.ConnectionConfiguration),
+		sourceMailchimpConnConfigSecrets(&plan.ConnectionConfiguration),
+	)
+
+	// Set refreshed state
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.SourceId,
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Delete deletes the resource and removes the state on success.
+func (r *sourceMailchimpResource) Delete(req *schema.ServiceRequest) *schema.ServiceResponse {
+	// Delete existing source
+	err := r.Client.DeleteMailchimpSource(req.StateID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{}
+}
+
+// Returns the API connection_configuration of the connection_configuration model.
+func sourceMailchimpConnConfigToAPI(m sourceMailchimpConnConfigModel) api.SourceMailchimpConnConfig {
+	c := api.SourceMailchimpConnConfig{}
+	c.StartDate = m.StartDate
+	if m.Credentials != nil {
+		v := sourceMailchimpCredentialsToAPI(*m.Credentials)
+		c.Credentials = &v
+	}
+	return c
+}
+
+// Returns the connection_configuration model of the API connection_configuration.
+// Secrets are left as Airbyte returns them.
+func sourceMailchimpConnConfigFromAPI(c api.SourceMailchimpConnConfig) sourceMailchimpConnConfigModel {
+	m := sourceMailchimpConnConfigModel{}
+	m.StartDate = c.StartDate
+	if c.Credentials != nil {
+		v := sourceMailchimpCredentialsFromAPI(*c.Credentials)
+		m.Credentials = &v
+	}
+	return m
+}
+
+// Checks the connection_configuration values Airbyte would reject.
+func sourceMailchimpConnConfigValidate(m sourceMailchimpConnConfigModel) error {
+	if m.StartDate != nil {
+		if err := validatePattern("connection_configuration.start_date", *m.StartDate, `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}\.[0-9]{3}Z$`); err != nil {
+			return err
+		}
+	}
+	if m.Credentials != nil {
+		if err := sourceMailchimpCredentialsValidate(*m.Credentials); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the secrets set in the connection_configuration model, in a stable order.
+func sourceMailchimpConnConfigSecrets(c *sourceMailchimpConnConfigModel) []*string {
+	secrets := []*string{}
+	if c.Credentials != nil {
+		secrets = append(secrets, sourceMailchimpCredentialsSecrets(c.Credentials)...)
+	}
+	return secrets
+}
+
+// Returns the secrets set in the connection_configuration API body, in a stable order.
+func sourceMailchimpConnConfigAPISecrets(c *api.SourceMailchimpConnConfig) []*string {
+	secrets := []*string{}
+	if c.Credentials != nil {
+		secrets = append(secrets, sourceMailchimpCredentialsAPISecrets(c.Credentials)...)
+	}
+	return secrets
+}
+
+// Returns the API credentials of the credentials model, with auth_type set
+// to the value of the configured block.
+func sourceMailchimpCredentialsToAPI(m sourceMailchimpCredentialsModel) api.SourceMailchimpCredentials {
+	c := api.SourceMailchimpCredentials{}
+	if m.Oauth20 != nil {
+		c.AuthType = "oauth2.0"
+		c.AccessToken = m.Oauth20.AccessToken
+		if m.Oauth20.ClientId != nil {
+			v := *m.Oauth20.ClientId
+			c.ClientId = &v
+		}
+		if m.Oauth20.ClientSecret != nil {
+			v := *m.Oauth20.ClientSecret
+			c.ClientSecret = &v
+		}
+	}
+	if m.Apikey != nil {
+		c.AuthType = "apikey"
+		c.Apikey = m.Apikey.Apikey
+	}
+	return c
+}
+
+// Returns the credentials model of the API credentials, with the block
+// matching auth_type set. Secrets are left as Airbyte returns them.
+func sourceMailchimpCredentialsFromAPI(c api.SourceMailchimpCredentials) sourceMailchimpCredentialsModel {
+	m := sourceMailchimpCredentialsModel{}
+	switch c.AuthType {
+	case "oauth2.0":
+		v := sourceMailchimpCredentialsOauth20Model{}
+		v.AccessToken = c.AccessToken
+		v.ClientId = c.ClientId
+		v.ClientSecret = c.ClientSecret
+		m.Oauth20 = &v
+	case "apikey":
+		v := sourceMailchimpCredentialsApikeyModel{}
+		v.Apikey = c.Apikey
+		m.Apikey = &v
+	}
+	return m
+}
+
+// Checks that exactly one credentials block is set, and its values.
+func sourceMailchimpCredentialsValidate(m sourceMailchimpCredentialsModel) error {
+	if err := sourceMailchimpCredentials.validate(m.Oauth20 != nil, m.Apikey != nil); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Returns the secrets set in the credentials model, in a stable order.
+func sourceMailchimpCredentialsSecrets(c *sourceMailchimpCredentialsModel) []*string {
+	secrets := []*string{}
+	if c.Oauth20 != nil {
+		secrets = append(secrets, &c.Oauth20.AccessToken)
+		if c.Oauth20.ClientId != nil {
+			secrets = append(secrets, c.Oauth20.ClientId)
+		}
+		if c.Oauth20.ClientSecret != nil {
+			secrets = append(secrets, c.Oauth20.ClientSecret)
+		}
+	}
+	if c.Apikey != nil {
+		secrets = append(secrets, &c.Apikey.Apikey)
+	}
+	return secrets
+}
+
+// Returns the secrets set in the credentials API body, in a stable order.
+func sourceMailchimpCredentialsAPISecrets(c *api.SourceMailchimpCredentials) []*string {
+	secrets := []*string{}
+	switch c.AuthType {
+	case "oauth2.0":
+		secrets = append(secrets, &c.AccessToken)
+		if c.ClientId != nil {
+			secrets = append(secrets, c.ClientId)
+		}
+		if c.ClientSecret != nil {
+			secrets = append(secrets, c.ClientSecret)
+		}
+	case "apikey":
+		secrets = append(secrets, &c.Apikey)
+	}
+	return secrets
+}
@@ -0,0 +1,96 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceMailchimpResource(t *testing.T) {
+	t.Run("oauth", func(t *testing.T) {
+		sourceTest{
+			resource:     plugin.NewSourceMailchimpResource,
+			definitionId: mailchimpDefinitionId,
+			create: plugintest.Values{
+				"credentials": plugintest.Values{
+					"oauth2_0": plugintest.Values{"access_token": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00.000Z",
+				"credentials": plugintest.Values{
+					"oauth2_0": plugintest.Values{"access_token": "rotated"},
+				},
+			},
+			secret: "connection_configuration.credentials.oauth2_0.access_token",
+		}.run(t)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		sourceTest{
+			resource:     plugin.NewSourceMailchimpResource,
+			definitionId: mailchimpDefinitionId,
+			create: plugintest.Values{
+				"credentials": plugintest.Values{
+					"apikey": plugintest.Values{"apikey": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"credentials": plugintest.Values{
+					"apikey": plugintest.Values{"apikey": "rotated"},
+				},
+			},
+			secret: "connection_configuration.credentials.apikey.apikey",
+		}.run(t)
+	})
+}
+
+func TestSourceMailchimpResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceMailchimpResource())
+	config := func(startDate interface{}, credentials plugintest.Values) plugintest.Values {
+		return plugintest.Values{
+			"name":                 "mailchimp",
+			"source_definition_id": mailchimpDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"start_date":  startDate,
+				"credentials": credentials,
+			},
+		}
+	}
+	oauth := plugintest.Values{
+		"oauth2_0": plugintest.Values{"access_token": "secret", "client_id": "client", "client_secret": "client secret"},
+	}
+
+	// The block is sent with the auth type the connector tells the
+	// variants apart by.
+	created := h.Create(config(nil, oauth))
+	if got := storedString(s, created.ID, "credentials.auth_type"); got != "oauth2.0" {
+		t.Errorf("auth_type = %q sent, want oauth2.0", got)
+	}
+
+	// Mailchimp marks the client ID as secret, so Airbyte masks it and
+	// the configured one is kept.
+	read := h.Read(created)
+	for path, want := range map[string]string{"access_token": "secret", "client_id": "client", "client_secret": "client secret"} {
+		if got := read.String("connection_configuration.credentials.oauth2_0." + path); got != want {
+			t.Errorf("%s = %q after read, want the configured one", path, got)
+		}
+	}
+
+	updated := h.Update(read, config("2020-01-01T00:00:00.000Z", plugintest.Values{
+		"apikey": plugintest.Values{"apikey": "key"},
+	}))
+	if got := storedString(s, updated.ID, "credentials.auth_type"); got != "apikey" {
+		t.Errorf("auth_type = %q sent, want apikey", got)
+	}
+
+	// The connector only accepts UTC date-times with milliseconds.
+	_, err := h.TryCreate(config("2020-01-01T00:00:00Z", oauth))
+	if err == nil {
+		t.Errorf("start_date without milliseconds accepted, want an error")
+	}
+}
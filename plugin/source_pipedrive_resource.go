@@ -21,18 +21,77 @@ type sourcePipedriveResourceModel struct {
 	SourceDefinitionId      string                         `pctsdk:"source_definition_id"`
 	WorkspaceId             string                         `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourcePipedriveConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                          `pctsdk:"adopt_existing"`
+	SecretsHash             *string                        `pctsdk:"secrets_hash"`
 }
 
 type sourcePipedriveConnConfigModel struct {
-	ReplicationStartDate string                         `pctsdk:"replication_start_date"`
-	Authorization        sourcePipedriveAuthConfigModel `pctsdk:"authorization"`
+	ReplicationStartDate string                            `pctsdk:"replication_start_date"`
+	Authorization        sourcePipedriveAuthorizationModel `pctsdk:"authorization"`
 }
 
-type sourcePipedriveAuthConfigModel struct {
-	AuthType string `pctsdk:"auth_type"`
+type sourcePipedriveAuthorizationModel struct {
+	OAuth    *sourcePipedriveOAuthModel    `pctsdk:"oauth,omitempty"`
+	ApiToken *sourcePipedriveApiTokenModel `pctsdk:"api_token,omitempty"`
+}
+
+type sourcePipedriveOAuthModel struct {
+	ClientId     string `pctsdk:"client_id"`
+	ClientSecret string `pctsdk:"client_secret"`
+	RefreshToken string `pctsdk:"refresh_token"`
+}
+
+type sourcePipedriveApiTokenModel struct {
 	ApiToken string `pctsdk:"api_token"`
 }
 
+// Pipedrive auth types, which tell the authorizations apart.
+const (
+	pipedriveAuthTypeClient = "Client"
+	pipedriveAuthTypeToken  = "Token"
+)
+
+// Authorization blocks, one per Pipedrive auth type.
+var sourcePipedriveAuthorization = oneOf{
+	Path:          "connection_configuration.authorization",
+	Discriminator: "auth_type",
+	Variants: []oneOfVariant{
+		{
+			Key:         "oauth",
+			Value:       pipedriveAuthTypeClient,
+			Description: "Authenticate via an OAuth app",
+			Attributes: map[string]schema.Attribute{
+				"client_id": &schema.StringAttribute{
+					Description: "Client ID of the OAuth app",
+					Required:    true,
+				},
+				"client_secret": &schema.StringAttribute{
+					Description: "Client Secret of the OAuth app. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+				"refresh_token": &schema.StringAttribute{
+					Description: "Refresh Token to obtain new access tokens. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+		{
+			Key:         "api_token",
+			Value:       pipedriveAuthTypeToken,
+			Description: "Authenticate via an API token",
+			Attributes: map[string]schema.Attribute{
+				"api_token": &schema.StringAttribute{
+					Description: "API Token. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+	},
+}
+
 // Ensure the implementation satisfies the expected interfaces.
 var (
 	_ schema.ResourceService = &sourcePipedriveResource{}
@@ -57,19 +116,11 @@ func (r *sourcePipedriveResource) Configure(req *schema.ServiceRequest) *schema.
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -79,6 +130,8 @@ func (r *sourcePipedriveResource) Configure(req *schema.ServiceRequest) *schema.
 func (r *sourcePipedriveResource) Schema() *schema.ServiceResponse {
 	s := &schema.Schema{
 		Description: "Source pipedrive resource for Airbyte",
+		// Version 1 nests the authorization variants in blocks.
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
 			"name": &schema.StringAttribute{
 				Description: "Name",
@@ -90,13 +143,23 @@ func (r *sourcePipedriveResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
@@ -105,21 +168,7 @@ func (r *sourcePipedriveResource) Schema() *schema.ServiceResponse {
 						Description: "Replication Start Date",
 						Required:    true,
 					},
-					"authorization": &schema.MapAttribute{
-						Description: "authorization",
-						Required:    true,
-						Attributes: map[string]schema.Attribute{
-							"auth_type": &schema.StringAttribute{
-								Description: "Auth Type",
-								Required:    true,
-							},
-							"api_token": &schema.StringAttribute{
-								Description: "API Token",
-								Required:    true,
-								Sensitive:   true,
-							},
-						},
-					},
+					"authorization": sourcePipedriveAuthorization.attribute("Authorization, either oauth or api_token", true),
 				},
 			},
 		},
@@ -154,12 +203,36 @@ func (r *sourcePipedriveResource) Create(req *schema.ServiceRequest) *schema.Ser
 
 	body.ConnectionConfiguration = api.SourcePipedriveConnConfig{}
 	body.ConnectionConfiguration.ReplicationStartDate = plan.ConnectionConfiguration.ReplicationStartDate
-	body.ConnectionConfiguration.Authorization = api.SourcePipedriveAuthConfigModel{}
-	body.ConnectionConfiguration.Authorization.ApiToken = plan.ConnectionConfiguration.Authorization.ApiToken
-	body.ConnectionConfiguration.Authorization.AuthType = plan.ConnectionConfiguration.Authorization.AuthType
+	body.ConnectionConfiguration.Authorization, err = sourcePipedriveAuthorizationToAPI(plan.ConnectionConfiguration.Authorization)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourcePipedriveAuthorizationAPISecrets(&body.ConnectionConfiguration.Authorization)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
 
-	// Create new source
-	source, err := r.Client.CreatePipedriveSource(body)
+	var source api.SourcePipedrive
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdatePipedriveSource(body)
+	} else {
+		source, err = r.Client.CreatePipedriveSource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -170,12 +243,16 @@ func (r *sourcePipedriveResource) Create(req *schema.ServiceRequest) *schema.Ser
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourcePipedriveConnConfigModel{}
 	state.ConnectionConfiguration.ReplicationStartDate = source.ConnectionConfiguration.ReplicationStartDate
-	state.ConnectionConfiguration.Authorization = sourcePipedriveAuthConfigModel{}
-	state.ConnectionConfiguration.Authorization.ApiToken = source.ConnectionConfiguration.Authorization.ApiToken
-	state.ConnectionConfiguration.Authorization.AuthType = source.ConnectionConfiguration.Authorization.AuthType
+	state.ConnectionConfiguration.Authorization = sourcePipedriveAuthorizationFromAPI(source.ConnectionConfiguration.Authorization)
+	keepSecrets(nil,
+		sourcePipedriveAuthorizationSecrets(&state.ConnectionConfiguration.Authorization),
+		sourcePipedriveAuthorizationSecrets(&plan.ConnectionConfiguration.Authorization),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -196,11 +273,17 @@ func (r *sourcePipedriveResource) Read(req *schema.ServiceRequest) *schema.Servi
 
 	var state sourcePipedriveResourceModel
 
-	// Get current state
-	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	// Get current state, upgraded from earlier schema versions.
+	contents, err := sourcePipedriveAuthorization.upgradeState(req.StateContents)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = fwhelpers.UnpackModel(contents, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	prior := state
 
 	res := schema.ServiceResponse{}
 
@@ -219,9 +302,11 @@ func (r *sourcePipedriveResource) Read(req *schema.ServiceRequest) *schema.Servi
 
 		state.ConnectionConfiguration = sourcePipedriveConnConfigModel{}
 		state.ConnectionConfiguration.ReplicationStartDate = source.ConnectionConfiguration.ReplicationStartDate
-		state.ConnectionConfiguration.Authorization = sourcePipedriveAuthConfigModel{}
-		state.ConnectionConfiguration.Authorization.AuthType = source.ConnectionConfiguration.Authorization.AuthType
-		state.ConnectionConfiguration.Authorization.ApiToken = source.ConnectionConfiguration.Authorization.ApiToken
+		state.ConnectionConfiguration.Authorization = sourcePipedriveAuthorizationFromAPI(source.ConnectionConfiguration.Authorization)
+		keepSecrets(state.SecretsHash,
+			sourcePipedriveAuthorizationSecrets(&state.ConnectionConfiguration.Authorization),
+			sourcePipedriveAuthorizationSecrets(&prior.ConnectionConfiguration.Authorization),
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -250,6 +335,16 @@ func (r *sourcePipedriveResource) Update(req *schema.ServiceRequest) *schema.Ser
 		return schema.ErrorResponse(err)
 	}
 
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
+
 	// Generate API request body from plan
 	body := api.SourcePipedrive{}
 	body.Name = plan.Name
@@ -257,9 +352,16 @@ func (r *sourcePipedriveResource) Update(req *schema.ServiceRequest) *schema.Ser
 
 	body.ConnectionConfiguration = api.SourcePipedriveConnConfig{}
 	body.ConnectionConfiguration.ReplicationStartDate = plan.ConnectionConfiguration.ReplicationStartDate
-	body.ConnectionConfiguration.Authorization = api.SourcePipedriveAuthConfigModel{}
-	body.ConnectionConfiguration.Authorization.AuthType = plan.ConnectionConfiguration.Authorization.AuthType
-	body.ConnectionConfiguration.Authorization.ApiToken = plan.ConnectionConfiguration.Authorization.ApiToken
+	body.ConnectionConfiguration.Authorization, err = sourcePipedriveAuthorizationToAPI(plan.ConnectionConfiguration.Authorization)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourcePipedriveAuthorizationAPISecrets(&body.ConnectionConfiguration.Authorization)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Update existing source
 	_, err = r.Client.UpdatePipedriveSource(body)
@@ -279,12 +381,16 @@ func (r *sourcePipedriveResource) Update(req *schema.ServiceRequest) *schema.Ser
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourcePipedriveConnConfigModel{}
 	state.ConnectionConfiguration.ReplicationStartDate = source.ConnectionConfiguration.ReplicationStartDate
-	state.ConnectionConfiguration.Authorization = sourcePipedriveAuthConfigModel{}
-	state.ConnectionConfiguration.Authorization.AuthType = source.ConnectionConfiguration.Authorization.AuthType
-	state.ConnectionConfiguration.Authorization.ApiToken = source.ConnectionConfiguration.Authorization.ApiToken
+	state.ConnectionConfiguration.Authorization = sourcePipedriveAuthorizationFromAPI(source.ConnectionConfiguration.Authorization)
+	keepSecrets(nil,
+		sourcePipedriveAuthorizationSecrets(&state.ConnectionConfiguration.Authorization),
+		sourcePipedriveAuthorizationSecrets(&plan.ConnectionConfiguration.Authorization),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -309,3 +415,68 @@ func (r *sourcePipedriveResource) Delete(req *schema.ServiceRequest) *schema.Ser
 
 	return &schema.ServiceResponse{}
 }
+
+// Returns the API authorization of the authorization model, with
+// auth_type set to the value of the configured block.
+func sourcePipedriveAuthorizationToAPI(m sourcePipedriveAuthorizationModel) (api.SourcePipedriveAuthConfigModel, error) {
+	c := api.SourcePipedriveAuthConfigModel{}
+	err := sourcePipedriveAuthorization.validate(m.OAuth != nil, m.ApiToken != nil)
+	if err != nil {
+		return c, err
+	}
+
+	if m.OAuth != nil {
+		c.AuthType = pipedriveAuthTypeClient
+		c.ClientId = m.OAuth.ClientId
+		c.ClientSecret = m.OAuth.ClientSecret
+		c.RefreshToken = m.OAuth.RefreshToken
+	}
+	if m.ApiToken != nil {
+		c.AuthType = pipedriveAuthTypeToken
+		c.ApiToken = m.ApiToken.ApiToken
+	}
+	return c, nil
+}
+
+// Returns the authorization model of the API authorization, with the
+// block matching auth_type set. Secrets are left as Airbyte returns them.
+func sourcePipedriveAuthorizationFromAPI(c api.SourcePipedriveAuthConfigModel) sourcePipedriveAuthorizationModel {
+	m := sourcePipedriveAuthorizationModel{}
+	switch c.AuthType {
+	case pipedriveAuthTypeClient:
+		v := sourcePipedriveOAuthModel{}
+		v.ClientId = c.ClientId
+		v.ClientSecret = c.ClientSecret
+		v.RefreshToken = c.RefreshToken
+		m.OAuth = &v
+	case pipedriveAuthTypeToken:
+		v := sourcePipedriveApiTokenModel{}
+		v.ApiToken = c.ApiToken
+		m.ApiToken = &v
+	}
+	return m
+}
+
+// Returns the secrets set in the authorization model, in a stable order.
+func sourcePipedriveAuthorizationSecrets(c *sourcePipedriveAuthorizationModel) []*string {
+	secrets := []*string{}
+	if c.OAuth != nil {
+		secrets = append(secrets, &c.OAuth.ClientSecret, &c.OAuth.RefreshToken)
+	}
+	if c.ApiToken != nil {
+		secrets = append(secrets, &c.ApiToken.ApiToken)
+	}
+	return secrets
+}
+
+// Returns the secrets set in the authorization API body, in a stable order.
+func sourcePipedriveAuthorizationAPISecrets(c *api.SourcePipedriveAuthConfigModel) []*string {
+	secrets := []*string{}
+	switch c.AuthType {
+	case pipedriveAuthTypeClient:
+		secrets = append(secrets, &c.ClientSecret, &c.RefreshToken)
+	case pipedriveAuthTypeToken:
+		secrets = append(secrets, &c.ApiToken)
+	}
+	return secrets
+}
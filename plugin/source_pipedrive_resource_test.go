@@ -0,0 +1,105 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourcePipedriveResource(t *testing.T) {
+	t.Run("oauth", func(t *testing.T) {
+		sourceTest{
+			resource:     plugin.NewSourcePipedriveResource,
+			definitionId: pipedriveDefinitionId,
+			create: plugintest.Values{
+				"replication_start_date": "2020-01-01T00:00:00Z",
+				"authorization": plugintest.Values{
+					"oauth": plugintest.Values{
+						"client_id":     "client",
+						"client_secret": "secret",
+						"refresh_token": "refresh",
+					},
+				},
+			},
+			update: plugintest.Values{
+				"replication_start_date": "2020-01-01T00:00:00Z",
+				"authorization": plugintest.Values{
+					"oauth": plugintest.Values{
+						"client_id":     "client",
+						"client_secret": "rotated",
+						"refresh_token": "refresh",
+					},
+				},
+			},
+			secret: "connection_configuration.authorization.oauth.client_secret",
+		}.run(t)
+	})
+
+	t.Run("api token", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourcePipedriveResource,
+			definitionId: pipedriveDefinitionId,
+			create: plugintest.Values{
+				"replication_start_date": "2020-01-01T00:00:00Z",
+				"authorization": plugintest.Values{
+					"api_token": plugintest.Values{"api_token": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"replication_start_date": "2020-01-01T00:00:00Z",
+				"authorization": plugintest.Values{
+					"api_token": plugintest.Values{"api_token": "rotated"},
+				},
+			},
+			secret: "connection_configuration.authorization.api_token.api_token",
+		}
+		st.run(t)
+		st.runUpgrade(t, "authorization", "auth_type", plugintest.Values{
+			"auth_type": "Token",
+			"api_token": "secret",
+		})
+	})
+}
+
+func TestSourcePipedriveResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourcePipedriveResource())
+	config := func(authorization plugintest.Values) plugintest.Values {
+		return plugintest.Values{
+			"name":                 "pipedrive",
+			"source_definition_id": pipedriveDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"replication_start_date": "2020-01-01T00:00:00Z",
+				"authorization":          authorization,
+			},
+		}
+	}
+
+	// The block is sent with the auth type the connector tells the
+	// variants apart by.
+	created := h.Create(config(plugintest.Values{
+		"oauth": plugintest.Values{"client_id": "client", "client_secret": "secret", "refresh_token": "refresh"},
+	}))
+	if got := storedString(s, created.ID, "authorization.auth_type"); got != "Client" {
+		t.Errorf("auth_type = %q sent, want Client", got)
+	}
+
+	// Pipedrive doesn't mark the client ID as secret, so it is read back
+	// from Airbyte unmasked.
+	read := h.Read(created)
+	for path, want := range map[string]string{"client_id": "client", "client_secret": "secret", "refresh_token": "refresh"} {
+		if got := read.String("connection_configuration.authorization.oauth." + path); got != want {
+			t.Errorf("%s = %q after read, want %q", path, got, want)
+		}
+	}
+
+	updated := h.Update(read, config(plugintest.Values{
+		"api_token": plugintest.Values{"api_token": "token"},
+	}))
+	if got := storedString(s, updated.ID, "authorization.auth_type"); got != "Token" {
+		t.Errorf("auth_type = %q sent, want Token", got)
+	}
+}
@@ -0,0 +1,282 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/zipstack/pct-plugin-framework/fwhelpers"
+	"github.com/zipstack/pct-plugin-framework/schema"
+
+	"github.com/zipstack/pct-provider-airbyte-local/api"
+)
+
+// Data source implementation.
+// Data sources are served as resources which only ever read
+// from Airbyte, so Create and Update refresh and Delete is a no-op.
+type sourceSchemaDataSource struct {
+	Client *api.Client
+}
+
+type sourceSchemaDataSourceModel struct {
+	SourceID     string                    `pctsdk:"source_id"`
+	DisableCache *bool                     `pctsdk:"disable_cache"`
+	CatalogID    string                    `pctsdk:"catalog_id"`
+	Streams      []sourceSchemaStreamModel `pctsdk:"streams"`
+}
+
+type sourceSchemaStreamModel struct {
+	Name                    string            `pctsdk:"name"`
+	Namespace               string            `pctsdk:"namespace"`
+	SupportedSyncModes      []string          `pctsdk:"supported_sync_modes"`
+	SourceDefinedCursor     bool              `pctsdk:"source_defined_cursor"`
+	DefaultCursorField      []string          `pctsdk:"default_cursor_field"`
+	SourceDefinedPrimaryKey [][]string        `pctsdk:"source_defined_primary_key"`
+	JSONSchemaProperties    map[string]string `pctsdk:"json_schema_properties"`
+}
+
+// Ensure the implementation satisfies the expected interfaces.
+var (
+	_ schema.ResourceService = &sourceSchemaDataSource{}
+)
+
+// Helper function to return a data source service instance.
+func NewSourceSchemaDataSource() schema.ResourceService {
+	return &sourceSchemaDataSource{}
+}
+
+// Metadata returns the data source type name.
+// It is always provider name + "_" + data source type name.
+func (r *sourceSchemaDataSource) Metadata(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{
+		TypeName: req.TypeName + "_source_schema",
+	}
+}
+
+// Configure adds the provider configured client to the data source.
+func (r *sourceSchemaDataSource) Configure(req *schema.ServiceRequest) *schema.ServiceResponse {
+	if req.ResourceData == "" {
+		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
+	}
+
+	client, err := configuredClient(req.ResourceData)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	r.Client = client
+
+	return &schema.ServiceResponse{}
+}
+
+// Schema defines the schema for the data source.
+func (r *sourceSchemaDataSource) Schema() *schema.ServiceResponse {
+	s := &schema.Schema{
+		Description: "Discovered source schema catalog data source for Airbyte",
+		Attributes: map[string]schema.Attribute{
+			"source_id": &schema.StringAttribute{
+				Description: "Source ID",
+				Required:    true,
+			},
+			"disable_cache": &schema.BoolAttribute{
+				Description: "Run a fresh discovery instead of using the cached catalog. Defaults to false.",
+				Optional:    true,
+			},
+			"catalog_id": &schema.StringAttribute{
+				Description: "Catalog ID",
+				Computed:    true,
+			},
+			"streams": &schema.ListAttribute{
+				Description: "Discovered streams, ordered by namespace and name",
+				Computed:    true,
+				NestedAttribute: &schema.MapAttribute{
+					Description: "Stream",
+					Computed:    true,
+					Attributes: map[string]schema.Attribute{
+						"name": &schema.StringAttribute{
+							Description: "Stream name",
+							Computed:    true,
+						},
+						"namespace": &schema.StringAttribute{
+							Description: "Stream namespace",
+							Computed:    true,
+						},
+						"supported_sync_modes": &schema.ListAttribute{
+							Description: "Supported sync modes",
+							Computed:    true,
+							NestedAttribute: &schema.StringAttribute{
+								Description: "Sync mode",
+								Computed:    true,
+							},
+						},
+						"source_defined_cursor": &schema.BoolAttribute{
+							Description: "Whether the source defines the cursor field",
+							Computed:    true,
+						},
+						"default_cursor_field": &schema.ListAttribute{
+							Description: "Default cursor field path",
+							Computed:    true,
+							NestedAttribute: &schema.StringAttribute{
+								Description: "Field name",
+								Computed:    true,
+							},
+						},
+						"source_defined_primary_key": &schema.ListAttribute{
+							Description: "Source defined primary key field paths",
+							Computed:    true,
+							NestedAttribute: &schema.ListAttribute{
+								Description: "Primary key field path",
+								Computed:    true,
+								NestedAttribute: &schema.StringAttribute{
+									Description: "Field name",
+									Computed:    true,
+								},
+							},
+						},
+						"json_schema_properties": &schema.MapAttribute{
+							Description: "JSON schema of each stream property as JSON, keyed by property name",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	sEnc, err := fwhelpers.Encode(s)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		SchemaContents: sEnc,
+	}
+}
+
+// Create reads the data source for the first time.
+func (r *sourceSchemaDataSource) Create(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var plan sourceSchemaDataSourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return r.refresh(plan.SourceID, plan.DisableCache)
+}
+
+// Read refreshes the data source information.
+func (r *sourceSchemaDataSource) Read(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var state sourceSchemaDataSourceModel
+
+	// Get current state
+	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	if req.StateID == "" {
+		// No previous state exists.
+		res := schema.ServiceResponse{}
+		stateEnc, err := fwhelpers.PackModel(nil, &state)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+		res.StateContents = stateEnc
+
+		return &res
+	}
+
+	return r.refresh(req.StateID, state.DisableCache)
+}
+
+func (r *sourceSchemaDataSource) Update(req *schema.ServiceRequest) *schema.ServiceResponse {
+	var plan sourceSchemaDataSourceModel
+	err := fwhelpers.UnpackModel(req.PlanContents, &plan)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return r.refresh(plan.SourceID, plan.DisableCache)
+}
+
+// Delete only removes the data source from state.
+func (r *sourceSchemaDataSource) Delete(req *schema.ServiceRequest) *schema.ServiceResponse {
+	return &schema.ServiceResponse{}
+}
+
+func (r *sourceSchemaDataSource) refresh(sourceId string, disableCache *bool) *schema.ServiceResponse {
+	discovered, err := r.Client.DiscoverSourceSchema(sourceId, disableCache != nil && *disableCache)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	state := sourceSchemaDataSourceModel{}
+	state.SourceID = sourceId
+	state.DisableCache = disableCache
+	state.CatalogID = discovered.CatalogId
+
+	state.Streams = []sourceSchemaStreamModel{}
+	for _, s := range discovered.Catalog.Streams {
+		stream := sourceSchemaStreamModel{}
+		stream.Name = s.Stream.Name
+		stream.Namespace = s.Stream.Namespace
+		stream.SupportedSyncModes = s.Stream.SupportedSyncModes
+		stream.SourceDefinedCursor = s.Stream.SourceDefinedCursor
+		stream.DefaultCursorField = s.Stream.DefaultCursorField
+		stream.SourceDefinedPrimaryKey = s.Stream.SourceDefinedPrimaryKey
+
+		stream.JSONSchemaProperties, err = jsonSchemaProperties(s.Stream.JsonSchema)
+		if err != nil {
+			return schema.ErrorResponse(fmt.Errorf(
+				"invalid JSON schema of stream %s: %s", s.Stream.Name, err.Error(),
+			))
+		}
+
+		state.Streams = append(state.Streams, stream)
+	}
+
+	sort.SliceStable(state.Streams, func(i, j int) bool {
+		if state.Streams[i].Namespace != state.Streams[j].Namespace {
+			return state.Streams[i].Namespace < state.Streams[j].Namespace
+		}
+		return state.Streams[i].Name < state.Streams[j].Name
+	})
+
+	stateEnc, err := fwhelpers.PackModel(nil, &state)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	return &schema.ServiceResponse{
+		StateID:          state.SourceID,
+		StateContents:    stateEnc,
+		StateLastUpdated: time.Now().Format(time.RFC850),
+	}
+}
+
+// Returns the normalized JSON schema of every top level
+// property of a stream JSON schema.
+func jsonSchemaProperties(jsonSchema json.RawMessage) (map[string]string, error) {
+	properties := map[string]string{}
+	if len(jsonSchema) == 0 {
+		return properties, nil
+	}
+
+	s := struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}{}
+	err := json.Unmarshal(jsonSchema, &s)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, property := range s.Properties {
+		properties[name], err = normalizeJSONBlob(property)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return properties, nil
+}
@@ -21,20 +21,77 @@ type sourceShopifyResourceModel struct {
 	SourceDefinitionId      string                       `pctsdk:"source_definition_id"`
 	WorkspaceId             string                       `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourceShopifyConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                        `pctsdk:"adopt_existing"`
+	SecretsHash             *string                      `pctsdk:"secrets_hash"`
 }
 
 type sourceShopifyConnConfigModel struct {
-	StartDate   string                 `pctsdk:"start_date"`
-	Shop        string                 `pctsdk:"shop"`
-	Credentials shopifyCredConfigModel `pctsdk:"credentials"`
+	StartDate   string                        `pctsdk:"start_date"`
+	Shop        string                        `pctsdk:"shop"`
+	Credentials sourceShopifyCredentialsModel `pctsdk:"credentials"`
 }
 
-type shopifyCredConfigModel struct {
-	AuthMethod   string `pctsdk:"auth_method"`
-	ApiPassword  string `pctsdk:"api_password"`
-	ClientSecret string `pctsdk:"client_secret"`
-	AccessToken  string `pctsdk:"access_token"`
-	ClientId     string `pctsdk:"client_id"`
+type sourceShopifyCredentialsModel struct {
+	OAuth       *sourceShopifyOAuthModel       `pctsdk:"oauth,omitempty"`
+	ApiPassword *sourceShopifyApiPasswordModel `pctsdk:"api_password,omitempty"`
+}
+
+type sourceShopifyOAuthModel struct {
+	AccessToken  string  `pctsdk:"access_token"`
+	ClientId     *string `pctsdk:"client_id"`
+	ClientSecret *string `pctsdk:"client_secret"`
+}
+
+type sourceShopifyApiPasswordModel struct {
+	ApiPassword string `pctsdk:"api_password"`
+}
+
+// Shopify auth methods, which tell the credentials apart.
+const (
+	shopifyAuthMethodOAuth2      = "oauth2.0"
+	shopifyAuthMethodAPIPassword = "api_password"
+)
+
+// Credentials blocks, one per Shopify auth method.
+var sourceShopifyCredentials = oneOf{
+	Path:          "connection_configuration.credentials",
+	Discriminator: "auth_method",
+	Variants: []oneOfVariant{
+		{
+			Key:         "oauth",
+			Value:       shopifyAuthMethodOAuth2,
+			Description: "Authenticate via OAuth",
+			Attributes: map[string]schema.Attribute{
+				"access_token": &schema.StringAttribute{
+					Description: "Access Token. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+				"client_id": &schema.StringAttribute{
+					Description: "Client ID of the app. May be a file:// or env:// secret reference",
+					Optional:    true,
+					Sensitive:   true,
+				},
+				"client_secret": &schema.StringAttribute{
+					Description: "Client Secret of the app. May be a file:// or env:// secret reference",
+					Optional:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+		{
+			Key:         "api_password",
+			Value:       shopifyAuthMethodAPIPassword,
+			Description: "Authenticate via the API password of a private app",
+			Attributes: map[string]schema.Attribute{
+				"api_password": &schema.StringAttribute{
+					Description: "API Password. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+	},
 }
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -61,19 +118,11 @@ func (r *sourceShopifyResource) Configure(req *schema.ServiceRequest) *schema.Se
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -83,6 +132,8 @@ func (r *sourceShopifyResource) Configure(req *schema.ServiceRequest) *schema.Se
 func (r *sourceShopifyResource) Schema() *schema.ServiceResponse {
 	s := &schema.Schema{
 		Description: "Source Shopify resource for Airbyte",
+		// Version 1 nests the credentials variants in blocks.
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
 			"name": &schema.StringAttribute{
 				Description: "Name",
@@ -93,13 +144,23 @@ func (r *sourceShopifyResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
@@ -112,36 +173,7 @@ func (r *sourceShopifyResource) Schema() *schema.ServiceResponse {
 						Description: "shop",
 						Required:    true,
 					},
-					"credentials": &schema.MapAttribute{
-						Description: "Connection configuration",
-						Required:    true,
-						Attributes: map[string]schema.Attribute{
-							"auth_method": &schema.StringAttribute{
-								Description: "Auth Method",
-								Required:    true,
-							},
-							"api_password": &schema.StringAttribute{
-								Description: "API Password",
-								Required:    true,
-								Sensitive:   true,
-							},
-							"client_secret": &schema.StringAttribute{
-								Description: "Client Secret",
-								Required:    true,
-								Sensitive:   true,
-							},
-							"access_token": &schema.StringAttribute{
-								Description: "Access Token",
-								Required:    true,
-								Sensitive:   true,
-							},
-							"client_id": &schema.StringAttribute{
-								Description: "Client ID",
-								Required:    true,
-								Sensitive:   true,
-							},
-						},
-					},
+					"credentials": sourceShopifyCredentials.attribute("Credentials, either oauth or api_password", true),
 				},
 			},
 		},
@@ -177,13 +209,36 @@ func (r *sourceShopifyResource) Create(req *schema.ServiceRequest) *schema.Servi
 	body.ConnectionConfiguration = api.SourceShopifyConnConfig{}
 	body.ConnectionConfiguration.StartDate = plan.ConnectionConfiguration.StartDate
 	body.ConnectionConfiguration.Shop = plan.ConnectionConfiguration.Shop
-	body.ConnectionConfiguration.Credentials = api.ShopifyCredConfigModel{}
-	body.ConnectionConfiguration.Credentials.AuthMethod = plan.ConnectionConfiguration.Credentials.AuthMethod
-	body.ConnectionConfiguration.Credentials.ApiPassword = plan.ConnectionConfiguration.Credentials.ApiPassword
-	body.ConnectionConfiguration.Credentials.ClientSecret = plan.ConnectionConfiguration.Credentials.ClientSecret
-	body.ConnectionConfiguration.Credentials.ClientId = plan.ConnectionConfiguration.Credentials.ClientId
-	// Create new source
-	source, err := r.Client.CreateShopifySource(body)
+	body.ConnectionConfiguration.Credentials, err = sourceShopifyCredentialsToAPI(plan.ConnectionConfiguration.Credentials)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourceShopifyCredentialsAPISecrets(&body.ConnectionConfiguration.Credentials)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var source api.SourceShopify
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateShopifySource(body)
+	} else {
+		source, err = r.Client.CreateShopifySource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -194,15 +249,17 @@ func (r *sourceShopifyResource) Create(req *schema.ServiceRequest) *schema.Servi
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourceShopifyConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.Shop = source.ConnectionConfiguration.Shop
-	state.ConnectionConfiguration.Credentials = shopifyCredConfigModel{}
-	state.ConnectionConfiguration.Credentials.AuthMethod = source.ConnectionConfiguration.Credentials.AuthMethod
-	state.ConnectionConfiguration.Credentials.ApiPassword = source.ConnectionConfiguration.Credentials.ApiPassword
-	state.ConnectionConfiguration.Credentials.ClientSecret = source.ConnectionConfiguration.Credentials.ClientSecret
-	state.ConnectionConfiguration.Credentials.ClientId = source.ConnectionConfiguration.Credentials.ClientId
+	state.ConnectionConfiguration.Credentials = sourceShopifyCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+	keepSecrets(nil,
+		sourceShopifyCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+		sourceShopifyCredentialsSecrets(&plan.ConnectionConfiguration.Credentials),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -223,12 +280,18 @@ func (r *sourceShopifyResource) Read(req *schema.ServiceRequest) *schema.Service
 
 	var state sourceShopifyResourceModel
 
-	// Get current state
-	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	// Get current state, upgraded from earlier schema versions.
+	contents, err := sourceShopifyCredentials.upgradeState(req.StateContents)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = fwhelpers.UnpackModel(contents, &state)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
+	prior := state
+
 	res := schema.ServiceResponse{}
 
 	if req.StateID != "" {
@@ -247,11 +310,11 @@ func (r *sourceShopifyResource) Read(req *schema.ServiceRequest) *schema.Service
 		state.ConnectionConfiguration = sourceShopifyConnConfigModel{}
 		state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 		state.ConnectionConfiguration.Shop = source.ConnectionConfiguration.Shop
-		state.ConnectionConfiguration.Credentials = shopifyCredConfigModel{}
-		state.ConnectionConfiguration.Credentials.AuthMethod = source.ConnectionConfiguration.Credentials.AuthMethod
-		state.ConnectionConfiguration.Credentials.ApiPassword = source.ConnectionConfiguration.Credentials.ApiPassword
-		state.ConnectionConfiguration.Credentials.ClientSecret = source.ConnectionConfiguration.Credentials.ClientSecret
-		state.ConnectionConfiguration.Credentials.ClientId = source.ConnectionConfiguration.Credentials.ClientId
+		state.ConnectionConfiguration.Credentials = sourceShopifyCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+		keepSecrets(state.SecretsHash,
+			sourceShopifyCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+			sourceShopifyCredentialsSecrets(&prior.ConnectionConfiguration.Credentials),
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -279,6 +342,16 @@ func (r *sourceShopifyResource) Update(req *schema.ServiceRequest) *schema.Servi
 		return schema.ErrorResponse(err)
 	}
 
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
+
 	// Generate API request body from plan
 	body := api.SourceShopify{}
 	body.Name = plan.Name
@@ -287,11 +360,17 @@ func (r *sourceShopifyResource) Update(req *schema.ServiceRequest) *schema.Servi
 	body.ConnectionConfiguration = api.SourceShopifyConnConfig{}
 	body.ConnectionConfiguration.StartDate = plan.ConnectionConfiguration.StartDate
 	body.ConnectionConfiguration.Shop = plan.ConnectionConfiguration.Shop
-	body.ConnectionConfiguration.Credentials = api.ShopifyCredConfigModel{}
-	body.ConnectionConfiguration.Credentials.AuthMethod = plan.ConnectionConfiguration.Credentials.AuthMethod
-	body.ConnectionConfiguration.Credentials.ApiPassword = plan.ConnectionConfiguration.Credentials.ApiPassword
-	body.ConnectionConfiguration.Credentials.ClientSecret = plan.ConnectionConfiguration.Credentials.ClientSecret
-	body.ConnectionConfiguration.Credentials.ClientId = plan.ConnectionConfiguration.Credentials.ClientId
+	body.ConnectionConfiguration.Credentials, err = sourceShopifyCredentialsToAPI(plan.ConnectionConfiguration.Credentials)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourceShopifyCredentialsAPISecrets(&body.ConnectionConfiguration.Credentials)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
 	// Update existing source
 	_, err = r.Client.UpdateShopifySource(body)
 	if err != nil {
@@ -310,15 +389,17 @@ func (r *sourceShopifyResource) Update(req *schema.ServiceRequest) *schema.Servi
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourceShopifyConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.Shop = source.ConnectionConfiguration.Shop
-	state.ConnectionConfiguration.Credentials = shopifyCredConfigModel{}
-	state.ConnectionConfiguration.Credentials.AuthMethod = source.ConnectionConfiguration.Credentials.AuthMethod
-	state.ConnectionConfiguration.Credentials.ApiPassword = source.ConnectionConfiguration.Credentials.ApiPassword
-	state.ConnectionConfiguration.Credentials.ClientSecret = source.ConnectionConfiguration.Credentials.ClientSecret
-	state.ConnectionConfiguration.Credentials.ClientId = source.ConnectionConfiguration.Credentials.ClientId
+	state.ConnectionConfiguration.Credentials = sourceShopifyCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+	keepSecrets(nil,
+		sourceShopifyCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+		sourceShopifyCredentialsSecrets(&plan.ConnectionConfiguration.Credentials),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -343,3 +424,69 @@ func (r *sourceShopifyResource) Delete(req *schema.ServiceRequest) *schema.Servi
 
 	return &schema.ServiceResponse{}
 }
+
+// Returns the API credentials of the credentials model, with auth_method
+// set to the value of the configured block.
+func sourceShopifyCredentialsToAPI(m sourceShopifyCredentialsModel) (api.ShopifyCredConfigModel, error) {
+	c := api.ShopifyCredConfigModel{}
+	err := sourceShopifyCredentials.validate(m.OAuth != nil, m.ApiPassword != nil)
+	if err != nil {
+		return c, err
+	}
+
+	if m.OAuth != nil {
+		c.AuthMethod = shopifyAuthMethodOAuth2
+		c.AccessToken = m.OAuth.AccessToken
+		c.ClientId = stringValue(m.OAuth.ClientId)
+		c.ClientSecret = stringValue(m.OAuth.ClientSecret)
+	}
+	if m.ApiPassword != nil {
+		c.AuthMethod = shopifyAuthMethodAPIPassword
+		c.ApiPassword = m.ApiPassword.ApiPassword
+	}
+	return c, nil
+}
+
+// Returns the credentials model of the API credentials, with the block
+// matching auth_method set. Secrets are left as Airbyte returns them.
+func sourceShopifyCredentialsFromAPI(c api.ShopifyCredConfigModel) sourceShopifyCredentialsModel {
+	m := sourceShopifyCredentialsModel{}
+	switch c.AuthMethod {
+	case shopifyAuthMethodOAuth2:
+		v := sourceShopifyOAuthModel{}
+		v.AccessToken = c.AccessToken
+		v.ClientId = optionalString(c.ClientId)
+		v.ClientSecret = optionalString(c.ClientSecret)
+		m.OAuth = &v
+	case shopifyAuthMethodAPIPassword:
+		v := sourceShopifyApiPasswordModel{}
+		v.ApiPassword = c.ApiPassword
+		m.ApiPassword = &v
+	}
+	return m
+}
+
+// Returns the secrets of the credentials model, in the order of
+// sourceShopifyCredentialsAPISecrets. Unset optional ones are nil.
+func sourceShopifyCredentialsSecrets(c *sourceShopifyCredentialsModel) []*string {
+	secrets := []*string{}
+	if c.OAuth != nil {
+		secrets = append(secrets, &c.OAuth.AccessToken, c.OAuth.ClientId, c.OAuth.ClientSecret)
+	}
+	if c.ApiPassword != nil {
+		secrets = append(secrets, &c.ApiPassword.ApiPassword)
+	}
+	return secrets
+}
+
+// Returns the secrets of the credentials API body, in a stable order.
+func sourceShopifyCredentialsAPISecrets(c *api.ShopifyCredConfigModel) []*string {
+	secrets := []*string{}
+	switch c.AuthMethod {
+	case shopifyAuthMethodOAuth2:
+		secrets = append(secrets, &c.AccessToken, &c.ClientId, &c.ClientSecret)
+	case shopifyAuthMethodAPIPassword:
+		secrets = append(secrets, &c.ApiPassword)
+	}
+	return secrets
+}
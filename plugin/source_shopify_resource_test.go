@@ -0,0 +1,117 @@
+package plugin_test
+
+import (
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceShopifyResource(t *testing.T) {
+	t.Run("oauth", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourceShopifyResource,
+			definitionId: shopifyDefinitionId,
+			create: plugintest.Values{
+				"start_date": "2020-01-01",
+				"shop":       "my-shop",
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{"access_token": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"start_date": "2020-01-01",
+				"shop":       "my-shop",
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{
+						"access_token":  "rotated",
+						"client_id":     "client",
+						"client_secret": "client secret",
+					},
+				},
+			},
+			secret: "connection_configuration.credentials.oauth.access_token",
+		}
+		st.run(t)
+		st.runUpgrade(t, "credentials", "auth_method", plugintest.Values{
+			"auth_method":   "oauth2.0",
+			"access_token":  "secret",
+			"client_id":     nil,
+			"client_secret": nil,
+			"api_password":  nil,
+		})
+	})
+
+	t.Run("api password", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourceShopifyResource,
+			definitionId: shopifyDefinitionId,
+			create: plugintest.Values{
+				"start_date": "2020-01-01",
+				"shop":       "my-shop",
+				"credentials": plugintest.Values{
+					"api_password": plugintest.Values{"api_password": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"start_date": "2020-01-01",
+				"shop":       "my-shop",
+				"credentials": plugintest.Values{
+					"api_password": plugintest.Values{"api_password": "rotated"},
+				},
+			},
+			secret: "connection_configuration.credentials.api_password.api_password",
+		}
+		st.run(t)
+		st.runUpgrade(t, "credentials", "auth_method", plugintest.Values{
+			"auth_method":   "api_password",
+			"access_token":  nil,
+			"client_id":     nil,
+			"client_secret": nil,
+			"api_password":  "secret",
+		})
+	})
+}
+
+func TestSourceShopifyResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceShopifyResource())
+	config := func(credentials plugintest.Values) plugintest.Values {
+		return plugintest.Values{
+			"name":                 "shopify",
+			"source_definition_id": shopifyDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"start_date":  "2020-01-01",
+				"shop":        "my-shop",
+				"credentials": credentials,
+			},
+		}
+	}
+
+	// The block is sent with the auth method the connector tells the
+	// variants apart by.
+	created := h.Create(config(plugintest.Values{
+		"oauth": plugintest.Values{"access_token": "secret", "client_id": "client", "client_secret": "client secret"},
+	}))
+	if got := storedString(s, created.ID, "credentials.auth_method"); got != "oauth2.0" {
+		t.Errorf("auth_method = %q sent, want oauth2.0", got)
+	}
+
+	// Shopify marks the client ID as secret, so Airbyte masks it and the
+	// configured one is kept.
+	read := h.Read(created)
+	for path, want := range map[string]string{"access_token": "secret", "client_id": "client", "client_secret": "client secret"} {
+		if got := read.String("connection_configuration.credentials.oauth." + path); got != want {
+			t.Errorf("%s = %q after read, want the configured one", path, got)
+		}
+	}
+
+	updated := h.Update(read, config(plugintest.Values{
+		"api_password": plugintest.Values{"api_password": "password"},
+	}))
+	if got := storedString(s, updated.ID, "credentials.auth_method"); got != "api_password" {
+		t.Errorf("auth_method = %q sent, want api_password", got)
+	}
+}
@@ -21,12 +21,16 @@ type sourceStripeResourceModel struct {
 	SourceDefinitionId      string                      `pctsdk:"source_definition_id"`
 	WorkspaceId             string                      `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourceStripeConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                       `pctsdk:"adopt_existing"`
+	SecretsHash             *string                     `pctsdk:"secrets_hash"`
 }
 
 type sourceStripeConnConfigModel struct {
 	StartDate          string `pctsdk:"start_date"`
-	LookbackWindowDays int    `pctsdk:"lookback_window_days"`
-	SliceRange         int    `pctsdk:"slice_range"`
+	LookbackWindowDays *int   `pctsdk:"lookback_window_days"`
+	SliceRange         *int   `pctsdk:"slice_range"`
+	NumWorkers         *int   `pctsdk:"num_workers"`
+	CallRateLimit      *int   `pctsdk:"call_rate_limit"`
 	ClientSecret       string `pctsdk:"client_secret"`
 	AccountId          string `pctsdk:"account_id"`
 }
@@ -55,19 +59,11 @@ func (r *sourceStripeResource) Configure(req *schema.ServiceRequest) *schema.Ser
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -87,31 +83,54 @@ func (r *sourceStripeResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
 				Attributes: map[string]schema.Attribute{
 					"start_date": &schema.StringAttribute{
-						Description: "Start Date",
-						Required:    true,
+						Description: "Start Date in UTC, formatted like 2017-01-25T00:00:00Z. " +
+							"Data created before it isn't replicated",
+						Required: true,
 					},
 					"slice_range": &schema.IntAttribute{
-						Description: "Slice Range",
-						Required:    true,
+						Description: "Number of days of data requested at once, at least 1. Defaults to 365",
+						Optional:    true,
+						Computed:    true,
 					},
 					"lookback_window_days": &schema.IntAttribute{
-						Description: "lookback window days",
-						Required:    true,
+						Description: "Number of days before the last sync to sync again, to catch updated data. Defaults to 0",
+						Optional:    true,
+						Computed:    true,
+					},
+					"num_workers": &schema.IntAttribute{
+						Description: "Number of concurrent workers, between 1 and 20. Defaults to 10",
+						Optional:    true,
+						Computed:    true,
+					},
+					"call_rate_limit": &schema.IntAttribute{
+						Description: "Maximum number of API calls per second. " +
+							"Defaults to the Stripe limit, 25 for test and 100 for live tokens",
+						Optional: true,
 					},
 					"client_secret": &schema.StringAttribute{
-						Description: "Client Secret",
+						Description: "Client Secret. May be a file:// or env:// secret reference",
 						Required:    true,
 						Sensitive:   true,
 					},
@@ -144,6 +163,10 @@ func (r *sourceStripeResource) Create(req *schema.ServiceRequest) *schema.Servic
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateStripeConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceStripe{}
@@ -157,9 +180,34 @@ func (r *sourceStripeResource) Create(req *schema.ServiceRequest) *schema.Servic
 	body.ConnectionConfiguration.AccountId = plan.ConnectionConfiguration.AccountId
 	body.ConnectionConfiguration.LookbackWindowDays = plan.ConnectionConfiguration.LookbackWindowDays
 	body.ConnectionConfiguration.SliceRange = plan.ConnectionConfiguration.SliceRange
+	body.ConnectionConfiguration.NumWorkers = plan.ConnectionConfiguration.NumWorkers
+	body.ConnectionConfiguration.CallRateLimit = plan.ConnectionConfiguration.CallRateLimit
+
+	// Resolve secret references right before the API call.
+	err = resolveSecrets(&body.ConnectionConfiguration.ClientSecret)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
 
-	// Create new source
-	source, err := r.Client.CreateStripeSource(body)
+	var source api.SourceStripe
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateStripeSource(body)
+	} else {
+		source, err = r.Client.CreateStripeSource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -170,13 +218,18 @@ func (r *sourceStripeResource) Create(req *schema.ServiceRequest) *schema.Servic
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(body.ConnectionConfiguration.ClientSecret)
 
 	state.ConnectionConfiguration = sourceStripeConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
-	state.ConnectionConfiguration.ClientSecret = source.ConnectionConfiguration.ClientSecret
+	state.ConnectionConfiguration.ClientSecret = plan.ConnectionConfiguration.ClientSecret
 	state.ConnectionConfiguration.AccountId = source.ConnectionConfiguration.AccountId
 	state.ConnectionConfiguration.LookbackWindowDays = source.ConnectionConfiguration.LookbackWindowDays
 	state.ConnectionConfiguration.SliceRange = source.ConnectionConfiguration.SliceRange
+	state.ConnectionConfiguration.NumWorkers = source.ConnectionConfiguration.NumWorkers
+	state.ConnectionConfiguration.CallRateLimit = source.ConnectionConfiguration.CallRateLimit
+	setStripeDefaults(&state.ConnectionConfiguration)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -203,6 +256,8 @@ func (r *sourceStripeResource) Read(req *schema.ServiceRequest) *schema.ServiceR
 		return schema.ErrorResponse(err)
 	}
 
+	prior := state
+
 	res := schema.ServiceResponse{}
 
 	if req.StateID != "" {
@@ -224,6 +279,14 @@ func (r *sourceStripeResource) Read(req *schema.ServiceRequest) *schema.ServiceR
 		state.ConnectionConfiguration.AccountId = source.ConnectionConfiguration.AccountId
 		state.ConnectionConfiguration.LookbackWindowDays = source.ConnectionConfiguration.LookbackWindowDays
 		state.ConnectionConfiguration.SliceRange = source.ConnectionConfiguration.SliceRange
+		state.ConnectionConfiguration.NumWorkers = source.ConnectionConfiguration.NumWorkers
+		state.ConnectionConfiguration.CallRateLimit = source.ConnectionConfiguration.CallRateLimit
+		setStripeDefaults(&state.ConnectionConfiguration)
+
+		keepSecrets(state.SecretsHash,
+			[]*string{&state.ConnectionConfiguration.ClientSecret},
+			[]*string{&prior.ConnectionConfiguration.ClientSecret},
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -250,6 +313,20 @@ func (r *sourceStripeResource) Update(req *schema.ServiceRequest) *schema.Servic
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateStripeConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceStripe{}
@@ -262,6 +339,14 @@ func (r *sourceStripeResource) Update(req *schema.ServiceRequest) *schema.Servic
 	body.ConnectionConfiguration.AccountId = plan.ConnectionConfiguration.AccountId
 	body.ConnectionConfiguration.LookbackWindowDays = plan.ConnectionConfiguration.LookbackWindowDays
 	body.ConnectionConfiguration.SliceRange = plan.ConnectionConfiguration.SliceRange
+	body.ConnectionConfiguration.NumWorkers = plan.ConnectionConfiguration.NumWorkers
+	body.ConnectionConfiguration.CallRateLimit = plan.ConnectionConfiguration.CallRateLimit
+
+	// Resolve secret references right before the API call.
+	err = resolveSecrets(&body.ConnectionConfiguration.ClientSecret)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Update existing source
 	_, err = r.Client.UpdateStripeSource(body)
@@ -281,13 +366,18 @@ func (r *sourceStripeResource) Update(req *schema.ServiceRequest) *schema.Servic
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(body.ConnectionConfiguration.ClientSecret)
 
 	state.ConnectionConfiguration = sourceStripeConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
-	state.ConnectionConfiguration.ClientSecret = source.ConnectionConfiguration.ClientSecret
+	state.ConnectionConfiguration.ClientSecret = plan.ConnectionConfiguration.ClientSecret
 	state.ConnectionConfiguration.AccountId = source.ConnectionConfiguration.AccountId
 	state.ConnectionConfiguration.LookbackWindowDays = source.ConnectionConfiguration.LookbackWindowDays
 	state.ConnectionConfiguration.SliceRange = source.ConnectionConfiguration.SliceRange
+	state.ConnectionConfiguration.NumWorkers = source.ConnectionConfiguration.NumWorkers
+	state.ConnectionConfiguration.CallRateLimit = source.ConnectionConfiguration.CallRateLimit
+	setStripeDefaults(&state.ConnectionConfiguration)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -312,3 +402,50 @@ func (r *sourceStripeResource) Delete(req *schema.ServiceRequest) *schema.Servic
 
 	return &schema.ServiceResponse{}
 }
+
+// Checks the connection_configuration values the Stripe connector would reject.
+func validateStripeConnConfig(c sourceStripeConnConfigModel) error {
+	path := "connection_configuration"
+	err := validatePattern(path+".start_date", c.StartDate, `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}Z$`)
+	if err != nil {
+		return err
+	}
+	// The pattern alone accepts dates like 2020-13-45T99:99:99Z.
+	_, err = time.Parse(time.RFC3339, c.StartDate)
+	if err != nil {
+		return fmt.Errorf("%s.start_date must be a valid date-time, got %q", path, c.StartDate)
+	}
+	if c.SliceRange != nil {
+		err = validateAtLeast(path+".slice_range", *c.SliceRange, 1)
+		if err != nil {
+			return err
+		}
+	}
+	if c.LookbackWindowDays != nil {
+		err = validateAtLeast(path+".lookback_window_days", *c.LookbackWindowDays, 0)
+		if err != nil {
+			return err
+		}
+	}
+	if c.NumWorkers != nil {
+		err = validateRange(path+".num_workers", *c.NumWorkers, 1, 20)
+		if err != nil {
+			return err
+		}
+	}
+	if c.CallRateLimit != nil {
+		err = validateAtLeast(path+".call_rate_limit", *c.CallRateLimit, 1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sets the connector defaults of the unset optional attributes, which are
+// left to the connector on create and update, so that they read back.
+func setStripeDefaults(c *sourceStripeConnConfigModel) {
+	c.SliceRange = intOrDefault(c.SliceRange, 365)
+	c.LookbackWindowDays = intOrDefault(c.LookbackWindowDays, 0)
+	c.NumWorkers = intOrDefault(c.NumWorkers, 10)
+}
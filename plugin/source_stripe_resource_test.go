@@ -0,0 +1,73 @@
+package plugin_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceStripeResource(t *testing.T) {
+	sourceTest{
+		resource:     plugin.NewSourceStripeResource,
+		definitionId: stripeDefinitionId,
+		create: plugintest.Values{
+			"account_id":    "acct_123",
+			"client_secret": "secret",
+			"start_date":    "2017-01-25T00:00:00Z",
+		},
+		update: plugintest.Values{
+			"account_id":    "acct_123",
+			"client_secret": "rotated",
+			"start_date":    "2017-01-25T00:00:00Z",
+			"slice_range":   30,
+		},
+		secret: "connection_configuration.client_secret",
+	}.run(t)
+}
+
+func TestSourceStripeResourceConfig(t *testing.T) {
+	s := newServer(t)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceStripeResource())
+	config := func(startDate string) plugintest.Values {
+		return plugintest.Values{
+			"name":                 "stripe",
+			"source_definition_id": stripeDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"account_id":    "acct_123",
+				"client_secret": "secret",
+				"start_date":    startDate,
+			},
+		}
+	}
+
+	// Unset optional attributes are left to the connector defaults.
+	created := h.Create(config("2017-01-25T00:00:00Z"))
+	connConfig := storedConfig(s, created.ID)
+	for _, name := range []string{"slice_range", "lookback_window_days", "num_workers"} {
+		if v, ok := connConfig[name]; ok {
+			t.Errorf("%s = %v sent, want it unset", name, v)
+		}
+	}
+
+	// They read back as the connector defaults, so they don't show up
+	// as changes.
+	read := h.Read(created)
+	defaults := map[string]string{"slice_range": "365", "lookback_window_days": "0", "num_workers": "10"}
+	for name, want := range defaults {
+		v, _ := read.Attr("connection_configuration." + name)
+		if got := fmt.Sprint(v); got != want {
+			t.Errorf("%s = %s after read, want the connector default %s", name, got, want)
+		}
+	}
+
+	// The connector only accepts UTC date-times without fractions.
+	for _, startDate := range []string{"2017-01-25T00:00:00+02:00", "2017-01-25T00:00:00.000Z", "2017-01-25", "2020-13-45T99:99:99Z"} {
+		_, err := h.TryCreate(config(startDate))
+		if err == nil {
+			t.Errorf("start_date %s accepted, want an error", startDate)
+		}
+	}
+}
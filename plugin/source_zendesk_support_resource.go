@@ -2,6 +2,7 @@ package plugin
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/zipstack/pct-plugin-framework/fwhelpers"
@@ -21,20 +22,84 @@ type sourceZendeskSupportResourceModel struct {
 	SourceDefinitionId      string                              `pctsdk:"source_definition_id"`
 	WorkspaceId             string                              `pctsdk:"workspace_id"`
 	ConnectionConfiguration sourceZendeskSupportConnConfigModel `pctsdk:"connection_configuration"`
+	AdoptExisting           *bool                               `pctsdk:"adopt_existing"`
+	SecretsHash             *string                             `pctsdk:"secrets_hash"`
 }
 
 type sourceZendeskSupportConnConfigModel struct {
-	StartDate        string                              `pctsdk:"start_date"`
-	Subdomain        string                              `pctsdk:"subdomain"`
-	IgnorePagination bool                                `pctsdk:"ignore_pagination"`
-	Credentials      sourceZendeskSupportCredConfigModel `pctsdk:"credentials"`
+	StartDate        string                               `pctsdk:"start_date"`
+	Subdomain        string                               `pctsdk:"subdomain"`
+	IgnorePagination *bool                                `pctsdk:"ignore_pagination"`
+	NumWorkers       *int                                 `pctsdk:"num_workers"`
+	Credentials      sourceZendeskSupportCredentialsModel `pctsdk:"credentials"`
 }
 
-type sourceZendeskSupportCredConfigModel struct {
-	Credentials string `pctsdk:"credentials"`
-	ApiToken    string `pctsdk:"api_token"`
-	Email       string `pctsdk:"email"`
-	AccessToken string `pctsdk:"access_token"`
+type sourceZendeskSupportCredentialsModel struct {
+	OAuth    *sourceZendeskSupportOAuthModel    `pctsdk:"oauth,omitempty"`
+	ApiToken *sourceZendeskSupportApiTokenModel `pctsdk:"api_token,omitempty"`
+}
+
+type sourceZendeskSupportOAuthModel struct {
+	AccessToken  string  `pctsdk:"access_token"`
+	ClientId     *string `pctsdk:"client_id"`
+	ClientSecret *string `pctsdk:"client_secret"`
+}
+
+type sourceZendeskSupportApiTokenModel struct {
+	Email    string `pctsdk:"email"`
+	ApiToken string `pctsdk:"api_token"`
+}
+
+// Zendesk credentials types, which tell the credentials apart.
+const (
+	zendeskCredentialsOAuth2   = "oauth2.0"
+	zendeskCredentialsApiToken = "api_token"
+)
+
+// Credentials blocks, one per Zendesk auth method.
+var sourceZendeskSupportCredentials = oneOf{
+	Path:          "connection_configuration.credentials",
+	Discriminator: "credentials",
+	Variants: []oneOfVariant{
+		{
+			Key:         "oauth",
+			Value:       zendeskCredentialsOAuth2,
+			Description: "Authenticate via OAuth",
+			Attributes: map[string]schema.Attribute{
+				"access_token": &schema.StringAttribute{
+					Description: "Access Token. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+				"client_id": &schema.StringAttribute{
+					Description: "Client ID of the OAuth app. May be a file:// or env:// secret reference",
+					Optional:    true,
+					Sensitive:   true,
+				},
+				"client_secret": &schema.StringAttribute{
+					Description: "Client Secret of the OAuth app. May be a file:// or env:// secret reference",
+					Optional:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+		{
+			Key:         "api_token",
+			Value:       zendeskCredentialsApiToken,
+			Description: "Authenticate via an API token",
+			Attributes: map[string]schema.Attribute{
+				"email": &schema.StringAttribute{
+					Description: "Email of the user the API token belongs to",
+					Required:    true,
+				},
+				"api_token": &schema.StringAttribute{
+					Description: "API Token. May be a file:// or env:// secret reference",
+					Required:    true,
+					Sensitive:   true,
+				},
+			},
+		},
+	},
 }
 
 // Ensure the implementation satisfies the expected interfaces.
@@ -61,19 +126,11 @@ func (r *sourceZendeskSupportResource) Configure(req *schema.ServiceRequest) *sc
 		return schema.ErrorResponse(fmt.Errorf("no data provided to configure resource"))
 	}
 
-	var creds map[string]string
-	err := fwhelpers.Decode(req.ResourceData, &creds)
+	client, err := configuredClient(req.ResourceData)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
-	client, err := api.NewClient(
-		creds["host"], creds["username"], creds["password"],
-	)
-	if err != nil {
-		return schema.ErrorResponse(fmt.Errorf("malformed data provided to configure resource"))
-	}
-
 	r.Client = client
 
 	return &schema.ServiceResponse{}
@@ -83,6 +140,8 @@ func (r *sourceZendeskSupportResource) Configure(req *schema.ServiceRequest) *sc
 func (r *sourceZendeskSupportResource) Schema() *schema.ServiceResponse {
 	s := &schema.Schema{
 		Description: "Source zendesk resource for Airbyte",
+		// Version 1 nests the credentials variants in blocks.
+		Version: 1,
 		Attributes: map[string]schema.Attribute{
 			"name": &schema.StringAttribute{
 				Description: "Name",
@@ -93,53 +152,47 @@ func (r *sourceZendeskSupportResource) Schema() *schema.ServiceResponse {
 				Computed:    true,
 			},
 			"source_definition_id": &schema.StringAttribute{
-				Description: "Definition ID",
+				Description: "Definition ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
 			"workspace_id": &schema.StringAttribute{
-				Description: "Workspace ID",
+				Description: "Workspace ID. Changing it replaces the source, which is refused while connections use it",
 				Required:    true,
 			},
+			"adopt_existing": &schema.BoolAttribute{
+				Description: "Adopt an existing source with the same name and definition in the workspace " +
+					"instead of creating a new one, e.g. after a create timed out. " +
+					"Fails when several sources match. Defaults to false.",
+				Optional: true,
+			},
+			"secrets_hash": &schema.StringAttribute{
+				Description: "Hash of the resolved secret values, used to detect rotated secret references",
+				Computed:    true,
+			},
 			"connection_configuration": &schema.MapAttribute{
 				Description: "Connection configuration",
 				Required:    true,
 				Attributes: map[string]schema.Attribute{
 					"start_date": &schema.StringAttribute{
-						Description: "Start Date",
-						Required:    true,
+						Description: "Start Date in UTC, formatted like 2020-10-15T00:00:00Z. " +
+							"Data created before it isn't replicated",
+						Required: true,
 					},
 					"subdomain": &schema.StringAttribute{
-						Description: "subdomain",
+						Description: "Subdomain of the Zendesk account, e.g. mycompany for mycompany.zendesk.com",
 						Required:    true,
 					},
 					"ignore_pagination": &schema.BoolAttribute{
-						Description: "ignore_pagination",
-						Required:    true,
+						Description: "Read a single page of data per stream. Deprecated by the connector. Defaults to false",
+						Optional:    true,
+						Computed:    true,
 					},
-					"credentials": &schema.MapAttribute{
-						Description: "credentials",
-						Required:    true,
-						Attributes: map[string]schema.Attribute{
-							"credentials": &schema.StringAttribute{
-								Description: "credentials",
-								Required:    true,
-							},
-							"api_token": &schema.StringAttribute{
-								Description: "API Token",
-								Required:    true,
-								Sensitive:   true,
-							},
-							"email": &schema.StringAttribute{
-								Description: "Email",
-								Required:    true,
-							},
-							"access_token": &schema.StringAttribute{
-								Description: "Access Token",
-								Required:    true,
-								Sensitive:   true,
-							},
-						},
+					"num_workers": &schema.IntAttribute{
+						Description: "Number of concurrent workers, between 1 and 40. Defaults to 3",
+						Optional:    true,
+						Computed:    true,
 					},
+					"credentials": sourceZendeskSupportCredentials.attribute("Credentials, either oauth or api_token", true),
 				},
 			},
 		},
@@ -165,6 +218,10 @@ func (r *sourceZendeskSupportResource) Create(req *schema.ServiceRequest) *schem
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateZendeskSupportConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceZendeskSupport{}
@@ -175,14 +232,38 @@ func (r *sourceZendeskSupportResource) Create(req *schema.ServiceRequest) *schem
 	body.ConnectionConfiguration = api.SourceZendeskSupportConnConfig{}
 	body.ConnectionConfiguration.StartDate = plan.ConnectionConfiguration.StartDate
 	body.ConnectionConfiguration.IgnorePagination = plan.ConnectionConfiguration.IgnorePagination
+	body.ConnectionConfiguration.NumWorkers = plan.ConnectionConfiguration.NumWorkers
 	body.ConnectionConfiguration.Subdomain = plan.ConnectionConfiguration.Subdomain
-	body.ConnectionConfiguration.Credentials = api.SourceZendeskSupportCredConfigModel{}
-	body.ConnectionConfiguration.Credentials.Credentials = plan.ConnectionConfiguration.Credentials.Credentials
-	body.ConnectionConfiguration.Credentials.ApiToken = plan.ConnectionConfiguration.Credentials.ApiToken
-	body.ConnectionConfiguration.Credentials.Email = plan.ConnectionConfiguration.Credentials.Email
-	body.ConnectionConfiguration.Credentials.AccessToken = plan.ConnectionConfiguration.Credentials.AccessToken
-	// Create new source
-	source, err := r.Client.CreateZendeskSupportSource(body)
+	body.ConnectionConfiguration.Credentials, err = sourceZendeskSupportCredentialsToAPI(plan.ConnectionConfiguration.Credentials)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourceZendeskSupportCredentialsAPISecrets(&body.ConnectionConfiguration.Credentials)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Adopt a matching existing source, e.g. one left over by a timed out
+	// create, or create a new one.
+	sourceId := ""
+	if boolValue(plan.AdoptExisting) {
+		sourceId, err = adoptableSourceId(r.Client, plan.WorkspaceId, plan.Name, plan.SourceDefinitionId)
+		if err != nil {
+			return schema.ErrorResponse(err)
+		}
+	}
+
+	var source api.SourceZendeskSupport
+	if sourceId != "" {
+		body.SourceId = sourceId
+		body.SourceDefinitionId = ""
+		body.WorkspaceId = ""
+		source, err = r.Client.UpdateZendeskSupportSource(body)
+	} else {
+		source, err = r.Client.CreateZendeskSupportSource(body)
+	}
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
@@ -193,16 +274,20 @@ func (r *sourceZendeskSupportResource) Create(req *schema.ServiceRequest) *schem
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourceZendeskSupportConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.IgnorePagination = source.ConnectionConfiguration.IgnorePagination
+	state.ConnectionConfiguration.NumWorkers = source.ConnectionConfiguration.NumWorkers
+	setZendeskSupportDefaults(&state.ConnectionConfiguration)
 	state.ConnectionConfiguration.Subdomain = source.ConnectionConfiguration.Subdomain
-	state.ConnectionConfiguration.Credentials = sourceZendeskSupportCredConfigModel{}
-	state.ConnectionConfiguration.Credentials.Credentials = source.ConnectionConfiguration.Credentials.Credentials
-	state.ConnectionConfiguration.Credentials.ApiToken = source.ConnectionConfiguration.Credentials.ApiToken
-	state.ConnectionConfiguration.Credentials.Email = source.ConnectionConfiguration.Credentials.Email
-	state.ConnectionConfiguration.Credentials.AccessToken = source.ConnectionConfiguration.Credentials.AccessToken
+	state.ConnectionConfiguration.Credentials = sourceZendeskSupportCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+	keepSecrets(nil,
+		sourceZendeskSupportCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+		sourceZendeskSupportCredentialsSecrets(&plan.ConnectionConfiguration.Credentials),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -223,12 +308,18 @@ func (r *sourceZendeskSupportResource) Read(req *schema.ServiceRequest) *schema.
 
 	var state sourceZendeskSupportResourceModel
 
-	// Get current state
-	err := fwhelpers.UnpackModel(req.StateContents, &state)
+	// Get current state, upgraded from earlier schema versions.
+	contents, err := sourceZendeskSupportCredentials.upgradeState(req.StateContents)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	err = fwhelpers.UnpackModel(contents, &state)
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
 
+	prior := state
+
 	res := schema.ServiceResponse{}
 
 	if req.StateID != "" {
@@ -247,12 +338,14 @@ func (r *sourceZendeskSupportResource) Read(req *schema.ServiceRequest) *schema.
 		state.ConnectionConfiguration = sourceZendeskSupportConnConfigModel{}
 		state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 		state.ConnectionConfiguration.IgnorePagination = source.ConnectionConfiguration.IgnorePagination
+		state.ConnectionConfiguration.NumWorkers = source.ConnectionConfiguration.NumWorkers
+		setZendeskSupportDefaults(&state.ConnectionConfiguration)
 		state.ConnectionConfiguration.Subdomain = source.ConnectionConfiguration.Subdomain
-		state.ConnectionConfiguration.Credentials = sourceZendeskSupportCredConfigModel{}
-		state.ConnectionConfiguration.Credentials.Credentials = source.ConnectionConfiguration.Credentials.Credentials
-		state.ConnectionConfiguration.Credentials.ApiToken = source.ConnectionConfiguration.Credentials.ApiToken
-		state.ConnectionConfiguration.Credentials.Email = source.ConnectionConfiguration.Credentials.Email
-		state.ConnectionConfiguration.Credentials.AccessToken = source.ConnectionConfiguration.Credentials.AccessToken
+		state.ConnectionConfiguration.Credentials = sourceZendeskSupportCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+		keepSecrets(state.SecretsHash,
+			sourceZendeskSupportCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+			sourceZendeskSupportCredentialsSecrets(&prior.ConnectionConfiguration.Credentials),
+		)
 
 		res.StateID = state.SourceId
 	} else {
@@ -279,6 +372,20 @@ func (r *sourceZendeskSupportResource) Update(req *schema.ServiceRequest) *schem
 	if err != nil {
 		return schema.ErrorResponse(err)
 	}
+	err = validateZendeskSupportConnConfig(plan.ConnectionConfiguration)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
+	// Airbyte can't move a source to another workspace or definition,
+	// so changing those replaces the source.
+	current, err := r.Client.ReadSource(req.PlanID)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	if current.WorkspaceId != plan.WorkspaceId || current.SourceDefinitionId != plan.SourceDefinitionId {
+		return replaceActor(r, req, r.Client, "source", current.WorkspaceId)
+	}
 
 	// Generate API request body from plan
 	body := api.SourceZendeskSupport{}
@@ -288,12 +395,19 @@ func (r *sourceZendeskSupportResource) Update(req *schema.ServiceRequest) *schem
 	body.ConnectionConfiguration = api.SourceZendeskSupportConnConfig{}
 	body.ConnectionConfiguration.StartDate = plan.ConnectionConfiguration.StartDate
 	body.ConnectionConfiguration.IgnorePagination = plan.ConnectionConfiguration.IgnorePagination
+	body.ConnectionConfiguration.NumWorkers = plan.ConnectionConfiguration.NumWorkers
 	body.ConnectionConfiguration.Subdomain = plan.ConnectionConfiguration.Subdomain
-	body.ConnectionConfiguration.Credentials = api.SourceZendeskSupportCredConfigModel{}
-	body.ConnectionConfiguration.Credentials.Credentials = plan.ConnectionConfiguration.Credentials.Credentials
-	body.ConnectionConfiguration.Credentials.ApiToken = plan.ConnectionConfiguration.Credentials.ApiToken
-	body.ConnectionConfiguration.Credentials.Email = plan.ConnectionConfiguration.Credentials.Email
-	body.ConnectionConfiguration.Credentials.AccessToken = plan.ConnectionConfiguration.Credentials.AccessToken
+	body.ConnectionConfiguration.Credentials, err = sourceZendeskSupportCredentialsToAPI(plan.ConnectionConfiguration.Credentials)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+	// Resolve secret references right before the API call.
+	secrets := sourceZendeskSupportCredentialsAPISecrets(&body.ConnectionConfiguration.Credentials)
+	err = resolveSecrets(secrets...)
+	if err != nil {
+		return schema.ErrorResponse(err)
+	}
+
 	// Update existing source
 	_, err = r.Client.UpdateZendeskSupportSource(body)
 	if err != nil {
@@ -312,16 +426,20 @@ func (r *sourceZendeskSupportResource) Update(req *schema.ServiceRequest) *schem
 	state.SourceDefinitionId = source.SourceDefinitionId
 	state.SourceId = source.SourceId
 	state.WorkspaceId = source.WorkspaceId
+	state.AdoptExisting = plan.AdoptExisting
+	state.SecretsHash = secretsHash(secretValues(secrets)...)
 
 	state.ConnectionConfiguration = sourceZendeskSupportConnConfigModel{}
 	state.ConnectionConfiguration.StartDate = source.ConnectionConfiguration.StartDate
 	state.ConnectionConfiguration.IgnorePagination = source.ConnectionConfiguration.IgnorePagination
+	state.ConnectionConfiguration.NumWorkers = source.ConnectionConfiguration.NumWorkers
+	setZendeskSupportDefaults(&state.ConnectionConfiguration)
 	state.ConnectionConfiguration.Subdomain = source.ConnectionConfiguration.Subdomain
-	state.ConnectionConfiguration.Credentials = sourceZendeskSupportCredConfigModel{}
-	state.ConnectionConfiguration.Credentials.Credentials = source.ConnectionConfiguration.Credentials.Credentials
-	state.ConnectionConfiguration.Credentials.ApiToken = source.ConnectionConfiguration.Credentials.ApiToken
-	state.ConnectionConfiguration.Credentials.Email = source.ConnectionConfiguration.Credentials.Email
-	state.ConnectionConfiguration.Credentials.AccessToken = source.ConnectionConfiguration.Credentials.AccessToken
+	state.ConnectionConfiguration.Credentials = sourceZendeskSupportCredentialsFromAPI(source.ConnectionConfiguration.Credentials)
+	keepSecrets(nil,
+		sourceZendeskSupportCredentialsSecrets(&state.ConnectionConfiguration.Credentials),
+		sourceZendeskSupportCredentialsSecrets(&plan.ConnectionConfiguration.Credentials),
+	)
 
 	// Set refreshed state
 	stateEnc, err := fwhelpers.PackModel(nil, &state)
@@ -346,3 +464,116 @@ func (r *sourceZendeskSupportResource) Delete(req *schema.ServiceRequest) *schem
 
 	return &schema.ServiceResponse{}
 }
+
+// Sets the connector defaults of the unset optional attributes, which are
+// left to the connector on create and update, so that they read back.
+func setZendeskSupportDefaults(c *sourceZendeskSupportConnConfigModel) {
+	c.IgnorePagination = boolOrDefault(c.IgnorePagination, false)
+	c.NumWorkers = intOrDefault(c.NumWorkers, 3)
+}
+
+// Checks the connection_configuration values the Zendesk Support
+// connector would reject.
+func validateZendeskSupportConnConfig(c sourceZendeskSupportConnConfigModel) error {
+	path := "connection_configuration"
+	err := validatePattern(path+".start_date", c.StartDate, `^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}:[0-9]{2}:[0-9]{2}Z$`)
+	if err != nil {
+		return err
+	}
+	// The pattern alone accepts dates like 2020-13-45T99:99:99Z.
+	_, err = time.Parse(time.RFC3339, c.StartDate)
+	if err != nil {
+		return fmt.Errorf("%s.start_date must be a valid date-time, got %q", path, c.StartDate)
+	}
+	err = validateZendeskSubdomain(path+".subdomain", c.Subdomain)
+	if err != nil {
+		return err
+	}
+	if c.NumWorkers != nil {
+		err = validateRange(path+".num_workers", *c.NumWorkers, 1, 40)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks that the subdomain is only the account name, e.g. mycompany,
+// as the connector adds the scheme and the .zendesk.com domain itself.
+func validateZendeskSubdomain(path string, subdomain string) error {
+	if strings.Contains(subdomain, "://") {
+		return fmt.Errorf("%s must not include a scheme, got %q", path, subdomain)
+	}
+	if strings.HasSuffix(strings.ToLower(strings.TrimSuffix(subdomain, "/")), ".zendesk.com") {
+		return fmt.Errorf("%s must not include the .zendesk.com domain, got %q", path, subdomain)
+	}
+	return validatePattern(path, subdomain, `^[A-Za-z0-9][A-Za-z0-9-]*$`)
+}
+
+// Returns the API credentials of the credentials model, with credentials
+// set to the value of the configured block.
+func sourceZendeskSupportCredentialsToAPI(m sourceZendeskSupportCredentialsModel) (api.SourceZendeskSupportCredConfigModel, error) {
+	c := api.SourceZendeskSupportCredConfigModel{}
+	err := sourceZendeskSupportCredentials.validate(m.OAuth != nil, m.ApiToken != nil)
+	if err != nil {
+		return c, err
+	}
+
+	if m.OAuth != nil {
+		c.Credentials = zendeskCredentialsOAuth2
+		c.AccessToken = m.OAuth.AccessToken
+		c.ClientId = stringValue(m.OAuth.ClientId)
+		c.ClientSecret = stringValue(m.OAuth.ClientSecret)
+	}
+	if m.ApiToken != nil {
+		c.Credentials = zendeskCredentialsApiToken
+		c.Email = m.ApiToken.Email
+		c.ApiToken = m.ApiToken.ApiToken
+	}
+	return c, nil
+}
+
+// Returns the credentials model of the API credentials, with the block
+// matching credentials set. Secrets are left as Airbyte returns them.
+func sourceZendeskSupportCredentialsFromAPI(c api.SourceZendeskSupportCredConfigModel) sourceZendeskSupportCredentialsModel {
+	m := sourceZendeskSupportCredentialsModel{}
+	switch c.Credentials {
+	case zendeskCredentialsOAuth2:
+		v := sourceZendeskSupportOAuthModel{}
+		v.AccessToken = c.AccessToken
+		v.ClientId = optionalString(c.ClientId)
+		v.ClientSecret = optionalString(c.ClientSecret)
+		m.OAuth = &v
+	case zendeskCredentialsApiToken:
+		v := sourceZendeskSupportApiTokenModel{}
+		v.Email = c.Email
+		v.ApiToken = c.ApiToken
+		m.ApiToken = &v
+	}
+	return m
+}
+
+// Returns the secrets of the credentials model, in the order of
+// sourceZendeskSupportCredentialsAPISecrets. Unset optional ones are nil.
+func sourceZendeskSupportCredentialsSecrets(c *sourceZendeskSupportCredentialsModel) []*string {
+	secrets := []*string{}
+	if c.OAuth != nil {
+		secrets = append(secrets, &c.OAuth.AccessToken, c.OAuth.ClientId, c.OAuth.ClientSecret)
+	}
+	if c.ApiToken != nil {
+		secrets = append(secrets, &c.ApiToken.ApiToken)
+	}
+	return secrets
+}
+
+// Returns the secrets of the credentials API body, in a stable order.
+func sourceZendeskSupportCredentialsAPISecrets(c *api.SourceZendeskSupportCredConfigModel) []*string {
+	secrets := []*string{}
+	switch c.Credentials {
+	case zendeskCredentialsOAuth2:
+		secrets = append(secrets, &c.AccessToken, &c.ClientId, &c.ClientSecret)
+	case zendeskCredentialsApiToken:
+		secrets = append(secrets, &c.ApiToken)
+	}
+	return secrets
+}
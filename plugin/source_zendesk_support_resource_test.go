@@ -0,0 +1,141 @@
+package plugin_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zipstack/pct-provider-airbyte-local/plugin"
+	"github.com/zipstack/pct-provider-airbyte-local/plugin/plugintest"
+)
+
+func TestSourceZendeskSupportResource(t *testing.T) {
+	t.Run("oauth", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourceZendeskSupportResource,
+			definitionId: zendeskSupportDefinitionId,
+			create: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"subdomain":  "mycompany",
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{"access_token": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"start_date":  "2020-01-01T00:00:00Z",
+				"subdomain":   "mycompany",
+				"num_workers": 5,
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{"access_token": "rotated"},
+				},
+			},
+			secret: "connection_configuration.credentials.oauth.access_token",
+		}
+		st.run(t)
+		st.runUpgrade(t, "credentials", "credentials", plugintest.Values{
+			"credentials":  "oauth2.0",
+			"access_token": "secret",
+			"email":        "",
+			"api_token":    "",
+		})
+	})
+
+	t.Run("api token", func(t *testing.T) {
+		st := sourceTest{
+			resource:     plugin.NewSourceZendeskSupportResource,
+			definitionId: zendeskSupportDefinitionId,
+			create: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"subdomain":  "mycompany",
+				"credentials": plugintest.Values{
+					"api_token": plugintest.Values{"email": "me@example.com", "api_token": "secret"},
+				},
+			},
+			update: plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"subdomain":  "mycompany",
+				"credentials": plugintest.Values{
+					"api_token": plugintest.Values{"email": "me@example.com", "api_token": "rotated"},
+				},
+			},
+			secret: "connection_configuration.credentials.api_token.api_token",
+		}
+		st.run(t)
+		st.runUpgrade(t, "credentials", "credentials", plugintest.Values{
+			"credentials":  "api_token",
+			"access_token": "",
+			"email":        "me@example.com",
+			"api_token":    "secret",
+		})
+	})
+}
+
+func TestSourceZendeskSupportResourceConfig(t *testing.T) {
+	s := newServer(t)
+	s.SetMaskSecrets(true)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceZendeskSupportResource())
+	config := func(subdomain string) plugintest.Values {
+		return plugintest.Values{
+			"name":                 "zendesk",
+			"source_definition_id": zendeskSupportDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"start_date": "2020-01-01T00:00:00Z",
+				"subdomain":  subdomain,
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{
+						"access_token":  "secret",
+						"client_id":     "client",
+						"client_secret": "client secret",
+					},
+				},
+			},
+		}
+	}
+
+	// The client ID is a secret of the connector, so Airbyte masks it
+	// and the configured one is kept.
+	created := h.Create(config("mycompany"))
+	read := h.Read(created)
+	if got := read.String("connection_configuration.credentials.oauth.client_id"); got != "client" {
+		t.Errorf("client_id = %q after read, want the configured one", got)
+	}
+
+	// Unset optional attributes read back as the connector defaults.
+	for name, want := range map[string]string{"ignore_pagination": "false", "num_workers": "3"} {
+		v, _ := read.Attr("connection_configuration." + name)
+		if got := fmt.Sprint(v); got != want {
+			t.Errorf("%s = %s after read, want the connector default %s", name, got, want)
+		}
+	}
+
+	// Only the subdomain is configured, the connector adds the domain.
+	_, err := h.TryCreate(config("mycompany.zendesk.com"))
+	if err == nil || !strings.Contains(err.Error(), "must not include the .zendesk.com domain") {
+		t.Errorf("TryCreate() error = %v, want the subdomain rejected", err)
+	}
+}
+
+func TestSourceZendeskSupportResourceStartDate(t *testing.T) {
+	s := newServer(t)
+	h := plugintest.New(t, plugintest.ProviderConfig(s), plugin.NewSourceZendeskSupportResource())
+
+	// The connector only accepts valid UTC date-times without fractions.
+	for _, startDate := range []string{"2020-01-01T00:00:00+02:00", "2020-01-01", "2020-13-45T99:99:99Z"} {
+		_, err := h.TryCreate(plugintest.Values{
+			"name":                 "zendesk",
+			"source_definition_id": zendeskSupportDefinitionId,
+			"workspace_id":         s.WorkspaceId(),
+			"connection_configuration": plugintest.Values{
+				"start_date": startDate,
+				"subdomain":  "mycompany",
+				"credentials": plugintest.Values{
+					"oauth": plugintest.Values{"access_token": "secret"},
+				},
+			},
+		})
+		if err == nil {
+			t.Errorf("start_date %s accepted, want an error", startDate)
+		}
+	}
+}
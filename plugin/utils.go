@@ -0,0 +1,55 @@
+package plugin
+
+// Returns nil for an empty string, so that unset optional
+// attributes stay null in state.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// Returns the value of an optional string attribute,
+// or an empty string when it is not set.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Returns the value of an optional bool attribute,
+// or false when it is not set.
+func boolValue(b *bool) bool {
+	if b == nil {
+		return false
+	}
+	return *b
+}
+
+// Returns an optional int attribute, or def when it is not set, so that
+// Optional and Computed attributes read back the connector default.
+func intOrDefault(i *int, def int) *int {
+	if i == nil {
+		return &def
+	}
+	return i
+}
+
+// Returns an optional bool attribute, or def when it is not set, so that
+// Optional and Computed attributes read back the connector default.
+func boolOrDefault(b *bool, def bool) *bool {
+	if b == nil {
+		return &def
+	}
+	return b
+}
+
+// Returns an optional string attribute, or def when it is not set, so that
+// Optional and Computed attributes read back the connector default.
+func stringOrDefault(s *string, def string) *string {
+	if s == nil {
+		return &def
+	}
+	return s
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Checks that a string attribute is one of the allowed values.
+func validateOneOf(path string, value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %q", path, strings.Join(allowed, ", "), value)
+}
+
+// Checks that a string attribute matches a pattern.
+func validatePattern(path string, value string, pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern for %s: %s", path, err.Error())
+	}
+	if !re.MatchString(value) {
+		return fmt.Errorf("%s must match %s, got %q", path, pattern, value)
+	}
+	return nil
+}
+
+// Checks that an int attribute is at least min.
+func validateAtLeast(path string, value int, min int) error {
+	if value < min {
+		return fmt.Errorf("%s must be at least %d, got %d", path, min, value)
+	}
+	return nil
+}
+
+// Checks that an int attribute is between min and max, inclusive.
+func validateRange(path string, value int, min int, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("%s must be between %d and %d, got %d", path, min, max, value)
+	}
+	return nil
+}
+
+// Checks that exactly one of the blocks of a group is set, where set
+// tells for each of the block names whether it is set.
+func validateExactlyOneOf(path string, names []string, set ...bool) error {
+	count := 0
+	for _, s := range set {
+		if s {
+			count++
+		}
+	}
+	if count != 1 {
+		return fmt.Errorf("%s needs exactly one of %s, got %d", path, strings.Join(names, ", "), count)
+	}
+	return nil
+}